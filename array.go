@@ -0,0 +1,67 @@
+package queue
+
+// An ArrayQueue is a queue stored in a fixed, caller-provided slice. It
+// never resizes, so it makes no allocations after construction, and Add
+// fails with ErrQueueFull once the slice is full.
+type ArrayQueue struct {
+	q *Queue
+}
+
+// NewArrayQueue constructs and returns a new ArrayQueue that stores its
+// elements in backing, which must not be empty and must not be used by the
+// caller afterwards. Any elements already in backing are cleared.
+func NewArrayQueue(backing []interface{}) *ArrayQueue {
+	if len(backing) == 0 {
+		panic("queue: NewArrayQueue() called with empty backing slice")
+	}
+	for i := range backing {
+		backing[i] = nil
+	}
+
+	return &ArrayQueue{
+		q: &Queue{buf: backing, fixed: true},
+	}
+}
+
+// Length returns the number of elements currently stored in the queue.
+func (a *ArrayQueue) Length() int {
+	return a.q.count
+}
+
+// Capacity returns the number of elements the queue can hold.
+func (a *ArrayQueue) Capacity() int {
+	return len(a.q.buf)
+}
+
+// Add puts an element on the end of the queue. This call returns
+// ErrQueueFull if the queue is full.
+func (a *ArrayQueue) Add(elem interface{}) error {
+	if a.q.count == len(a.q.buf) {
+		return ErrQueueFull
+	}
+	a.q.add(elem)
+	return nil
+}
+
+// Peek returns the element at the head of the queue. This call returns
+// ErrEmptyQueue if the queue is empty.
+func (a *ArrayQueue) Peek() (interface{}, error) {
+	return a.q.Peek()
+}
+
+// Get returns the element at index i in the queue. If the index is
+// invalid, the call returns ErrOutOfRange.
+func (a *ArrayQueue) Get(i int) (interface{}, error) {
+	return a.q.Get(i)
+}
+
+// Pop removes and returns the element at the head of the queue.
+func (a *ArrayQueue) Pop() (interface{}, error) {
+	return a.q.Pop()
+}
+
+// Remove removes the element from the front of the queue. This call returns
+// ErrEmptyQueue if the queue is empty.
+func (a *ArrayQueue) Remove() error {
+	return a.q.Remove()
+}
@@ -0,0 +1,61 @@
+package queue
+
+import "testing"
+
+func TestArrayQueue(t *testing.T) {
+	backing := make([]interface{}, 5)
+	q := NewArrayQueue(backing)
+
+	for round := 0; round < 10; round++ {
+		for i := 0; i < 5; i++ {
+			if err := q.Add(round + i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if q.Add(0) != ErrQueueFull {
+			t.Error("should error when adding to full queue")
+		}
+
+		for i := 0; i < 3; i++ {
+			if e, _ := q.Pop(); e.(int) != round+i {
+				t.Errorf("round %d pop %d had value %v", round, i, e)
+			}
+		}
+		for i := 0; i < 2; i++ {
+			q.Remove()
+		}
+		if err := q.q.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if &q.q.buf[0] != &backing[0] || q.Capacity() != 5 {
+		t.Error("queue stopped using its backing slice")
+	}
+}
+
+func TestArrayQueueAllocs(t *testing.T) {
+	q := NewArrayQueue(make([]interface{}, 64))
+	elem := new(int)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for i := 0; i < 64; i++ {
+			q.Add(elem)
+		}
+		for i := 0; i < 64; i++ {
+			q.Pop()
+		}
+	})
+	if allocs != 0 {
+		t.Error("filling and draining allocated", allocs, "times")
+	}
+}
+
+func TestArrayQueueEmptyBacking(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("should panic with empty backing slice")
+		}
+	}()
+	NewArrayQueue(nil)
+}
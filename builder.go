@@ -0,0 +1,40 @@
+package queue
+
+// A Builder accumulates elements for a new Queue, for building a large
+// queue in one go without the repeated resizes of adding to it directly.
+// The zero value is ready to use.
+type Builder struct {
+	elems []interface{}
+}
+
+// Add appends an element to the queue being built.
+func (b *Builder) Add(elem interface{}) {
+	b.elems = append(b.elems, elem)
+}
+
+// AddSlice appends all of elems, in order, to the queue being built.
+func (b *Builder) AddSlice(elems []interface{}) {
+	b.elems = append(b.elems, elems...)
+}
+
+// Length returns the number of elements added so far.
+func (b *Builder) Length() int {
+	return len(b.elems)
+}
+
+// Build returns a new Queue holding the added elements, in order, with its
+// head at the start of the buffer. The Builder's storage becomes the queue's
+// buffer without being copied, so the Builder must not be reused after Build.
+func (b *Builder) Build() *Queue {
+	if cap(b.elems) == 0 {
+		return New()
+	}
+
+	q := &Queue{
+		buf:   b.elems[:cap(b.elems)],
+		count: len(b.elems),
+	}
+	q.tail = q.count % len(q.buf)
+	b.elems = nil
+	return q
+}
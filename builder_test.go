@@ -0,0 +1,59 @@
+package queue
+
+import "testing"
+
+func TestBuilder(t *testing.T) {
+	var b Builder
+
+	for i := 0; i < 500; i++ {
+		b.Add(i)
+	}
+	b.AddSlice([]interface{}{500, 501})
+	if b.Length() != 502 {
+		t.Error("builder has length", b.Length())
+	}
+
+	elems := b.elems
+	q := b.Build()
+	if &q.buf[0] != &elems[0] {
+		t.Error("built queue copied the builder's storage")
+	}
+	if err := q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 502; i++ {
+		if e, _ := q.Get(i); e.(int) != i {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		q.Add(502 + i)
+	}
+	for i := 0; i < 1502; i++ {
+		if e, _ := q.Pop(); e.(int) != i {
+			t.Errorf("pop %d had value %v", i, e)
+		}
+	}
+}
+
+func TestBuilderFull(t *testing.T) {
+	b := Builder{elems: make([]interface{}, 0, 4)}
+	for i := 0; i < 4; i++ {
+		b.Add(i)
+	}
+
+	q := b.Build()
+	if err := q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	q.Add(4)
+	if e, _ := q.Get(4); q.Length() != 5 || e.(int) != 4 {
+		t.Error("adding to a full built queue failed")
+	}
+
+	var empty Builder
+	if q := empty.Build(); q.Length() != 0 {
+		t.Error("empty builder built queue of length", q.Length())
+	}
+}
@@ -0,0 +1,95 @@
+package queue
+
+import "sync/atomic"
+
+// CloneCOW returns a copy of the queue that shares its buffer with the
+// original until either of them is modified, at which point the modified
+// queue copies the buffer first. This makes cloning O(1), at the cost of an
+// extra check on every write and an O(n) copy on the first write after a
+// clone. The clone keeps the original's options, with its own copy of any
+// index, but does not inherit its reclaim func, tee or recorder.
+//
+// The original and its clones count how many of them share the buffer
+// atomically, so each may be used from a different goroutine; as with any
+// Queue, a single one of them must not be used concurrently.
+func (q *Queue) CloneCOW() *Queue {
+	h := q.ensureHooks()
+	if h.shared == nil {
+		h.shared = new(int32)
+		*h.shared = 1
+	}
+	atomic.AddInt32(h.shared, 1)
+
+	return q.cloneConfig()
+}
+
+// Clone returns a copy of the queue with a buffer of its own, sized to fit
+// just its contents. Like CloneCOW, the clone keeps the original's options
+// but not its reclaim func, tee or recorder.
+func (q *Queue) Clone() *Queue {
+	return q.CloneWithCapacity(0)
+}
+
+// CloneWithCapacity returns a copy of the queue, as Clone does, with room
+// for at least minCap elements before it needs to resize, for when the clone
+// will grow.
+func (q *Queue) CloneWithCapacity(minCap int) *Queue {
+	if minCap < q.count {
+		minCap = q.count
+	}
+	if minCap < minQueueLen {
+		minCap = minQueueLen
+	}
+
+	clone := q.cloneConfig()
+	clone.buf = make([]interface{}, minCap)
+	if clone.hooks != nil {
+		clone.hooks.shared = nil
+	}
+	q.copyInto(clone.buf)
+	clone.head = 0
+	clone.tail = q.count % minCap
+	return clone
+}
+
+// copies the queue struct, giving the copy its own index and dropping the
+// hooks that shouldn't fire twice for the same element
+func (q *Queue) cloneConfig() *Queue {
+	clone := *q
+	if q.hooks == nil {
+		return &clone
+	}
+
+	hooks := *q.hooks
+	hooks.reclaim = nil
+	hooks.tee = nil
+	hooks.recorder = nil
+	if q.hooks.index != nil {
+		hooks.index = make(map[interface{}]int, len(q.hooks.index))
+		for key, n := range q.hooks.index {
+			hooks.index[key] = n
+		}
+	}
+	clone.hooks = &hooks
+	return &clone
+}
+
+// gives the queue a buffer of its own before it is written to, copying it if
+// it is still shared with a clone
+func (q *Queue) unshare() {
+	if q.hooks == nil || q.hooks.shared == nil {
+		return
+	}
+	if atomic.LoadInt32(q.hooks.shared) > 1 {
+		q.buf = append([]interface{}(nil), q.buf...)
+	}
+	q.dropShare()
+}
+
+// stops sharing the buffer, for when the queue is about to replace it
+func (q *Queue) dropShare() {
+	if q.hooks != nil && q.hooks.shared != nil {
+		atomic.AddInt32(q.hooks.shared, -1)
+		q.hooks.shared = nil
+	}
+}
@@ -0,0 +1,161 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCloneCOWShares(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+	}
+
+	clone := q.CloneCOW()
+	if &clone.buf[0] != &q.buf[0] {
+		t.Error("clone should share the buffer until written")
+	}
+
+	clone.Add(10)
+	if &clone.buf[0] == &q.buf[0] {
+		t.Error("clone should copy the buffer when written")
+	}
+	if q.Length() != 10 || clone.Length() != 11 {
+		t.Error("lengths after adding to clone were", q.Length(), clone.Length())
+	}
+
+	// the original is now the only user of its buffer, so doesn't copy
+	buf := q.buf
+	q.Add(11)
+	if &q.buf[0] != &buf[0] {
+		t.Error("original copied a buffer it no longer shares")
+	}
+}
+
+func TestCloneCOWIndependent(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	a, b := q.CloneCOW(), q.CloneCOW()
+	for i := 0; i < 50; i++ {
+		q.Remove()
+	}
+	a.PrependSlice([]interface{}{-1})
+	b.DrainWhere(func(elem interface{}) bool { return elem.(int)%2 == 0 })
+
+	for i := 0; i < 50; i++ {
+		if e, _ := q.Get(i); e.(int) != i+50 {
+			t.Errorf("original index %d doesn't contain %d", i, i+50)
+		}
+	}
+	for i := 0; i < 101; i++ {
+		if e, _ := a.Get(i); e.(int) != i-1 {
+			t.Errorf("first clone index %d doesn't contain %d", i, i-1)
+		}
+	}
+	for i := 0; i < 50; i++ {
+		if e, _ := b.Get(i); e.(int) != i*2+1 {
+			t.Errorf("second clone index %d doesn't contain %d", i, i*2+1)
+		}
+	}
+
+	for _, c := range []*Queue{q, a, b} {
+		if err := c.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCloneCOWIndex(t *testing.T) {
+	q := NewWithOptions(WithIndex(func(elem interface{}) interface{} { return elem }))
+	q.Add(1)
+
+	clone := q.CloneCOW()
+	clone.Remove()
+
+	if !q.ContainsKey(1) || clone.ContainsKey(1) {
+		t.Error("clone shares its index with the original")
+	}
+}
+
+func TestClone(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+	}
+	for i := 0; i < 900; i++ {
+		q.Remove()
+	}
+	for i := 0; i < 10; i++ {
+		q.Add(1000 + i)
+	}
+
+	for _, c := range []struct {
+		clone    *Queue
+		capacity int
+	}{
+		{q.Clone(), 110},
+		{q.CloneWithCapacity(500), 500},
+		{q.CloneWithCapacity(5), 110},
+		{New().Clone(), minQueueLen},
+	} {
+		if len(c.clone.buf) != c.capacity {
+			t.Error("clone has capacity", len(c.clone.buf), "expected", c.capacity)
+		}
+		if err := c.clone.Validate(); err != nil {
+			t.Error(err)
+		}
+		if c.clone.Length() > 0 && c.clone.Length() != q.Length() {
+			t.Error("clone has length", c.clone.Length())
+		}
+	}
+
+	clone := q.Clone()
+	clone.Add(-1)
+	clone.Remove()
+	if e, _ := clone.Get(clone.Length() - 1); e.(int) != -1 {
+		t.Error("clone's last element is", e)
+	}
+	for i := 0; i < q.Length(); i++ {
+		if e, _ := q.Get(i); e.(int) != 900+i {
+			t.Errorf("original index %d doesn't contain %d", i, 900+i)
+		}
+	}
+}
+
+func TestCloneCOWConcurrent(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	// each queue is used by one goroutine, but they share a buffer; run
+	// with -race to check the sharing is synchronized
+	queues := []*Queue{q, q.CloneCOW(), q.CloneCOW()}
+	var wg sync.WaitGroup
+	for n, c := range queues {
+		wg.Add(1)
+		go func(n int, c *Queue) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.Remove()
+				c.Add(100*(n+1) + i)
+			}
+		}(n, c)
+	}
+	wg.Wait()
+
+	for n, c := range queues {
+		for i := 0; i < 100; i++ {
+			if e, _ := c.Get(i); e.(int) != 100*(n+1)+i {
+				t.Errorf("queue %d index %d doesn't contain %d", n, i, 100*(n+1)+i)
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package queue
+
+// A Cursor reads the elements of a Queue in order without removing them,
+// and can be rewound to the head at any time. Adding or removing elements
+// invalidates any active cursor over the queue.
+type Cursor struct {
+	q         *Queue
+	pos, mods int
+}
+
+// Cursor returns a new cursor positioned at the head of the queue.
+func (q *Queue) Cursor() *Cursor {
+	return &Cursor{q: q, mods: q.mods}
+}
+
+// Next returns the element at the cursor's position and advances the cursor.
+// The second return value is false once every element has been read.
+func (c *Cursor) Next() (interface{}, bool) {
+	c.q.checkMods(c.mods)
+	if c.pos >= c.q.count {
+		return nil, false
+	}
+	elem := c.q.buf[(c.q.head+c.pos)%len(c.q.buf)]
+	c.pos++
+	return elem, true
+}
+
+// Reset rewinds the cursor to the head of the queue, making it valid again
+// if the queue has been modified.
+func (c *Cursor) Reset() {
+	c.pos = 0
+	c.mods = c.q.mods
+}
+
+// Pos returns the number of elements read since the cursor was created or
+// last reset, which is also the index of the element Next will return.
+func (c *Cursor) Pos() int {
+	return c.pos
+}
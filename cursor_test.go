@@ -0,0 +1,56 @@
+package queue
+
+import "testing"
+
+func TestCursorNext(t *testing.T) {
+	q := New()
+
+	if _, ok := q.Cursor().Next(); ok {
+		t.Error("cursor over empty queue should be exhausted")
+	}
+
+	for i := 0; i < minQueueLen; i++ {
+		q.Add(i)
+	}
+	for i := 0; i < 3; i++ {
+		q.Remove()
+		q.Add(minQueueLen + i)
+	}
+
+	c := q.Cursor()
+	for i := 0; i < minQueueLen; i++ {
+		if c.Pos() != i {
+			t.Error("cursor at element", i, "has position", c.Pos())
+		}
+		if e, ok := c.Next(); !ok || e.(int) != i+3 {
+			t.Error("next", i, "had value", e)
+		}
+	}
+	if _, ok := c.Next(); ok {
+		t.Error("cursor should be exhausted")
+	}
+	if q.Length() != minQueueLen {
+		t.Error("cursor modified queue length to", q.Length())
+	}
+}
+
+func TestCursorReset(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+	}
+
+	c := q.Cursor()
+	for i := 0; i < 5; i++ {
+		c.Next()
+	}
+	c.Reset()
+
+	if c.Pos() != 0 {
+		t.Error("reset cursor has position", c.Pos())
+	}
+	if e, _ := c.Next(); e.(int) != 0 {
+		t.Error("reset cursor returned", e)
+	}
+}
@@ -0,0 +1,115 @@
+//go:build go1.7
+// +build go1.7
+
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrDispatcherStopped is returned when submitting to a stopped Dispatcher.
+var ErrDispatcherStopped = errors.New("queue: dispatcher stopped")
+
+// Dispatcher is a bounded worker pool that buffers submitted tasks in a
+// queue and runs each of them exactly once on one of its workers.
+type Dispatcher struct {
+	q        *Queue
+	capacity int
+	handler  func(interface{})
+
+	lock     *sync.Mutex
+	nonEmpty *sync.Cond
+	stopping bool
+	done     chan struct{}
+}
+
+// NewDispatcher starts a Dispatcher with the given number of workers, each
+// of which calls handler for tasks as they are submitted. At most queueCap
+// tasks may be waiting for a worker at any one time.
+func NewDispatcher(workers, queueCap int, handler func(interface{})) *Dispatcher {
+	if workers < 1 {
+		panic("queue: dispatcher needs at least one worker")
+	}
+	if queueCap < 1 {
+		panic("queue: dispatcher queue capacity must be at least 1")
+	}
+
+	lock := new(sync.Mutex)
+	d := &Dispatcher{
+		q:        New(),
+		capacity: queueCap,
+		handler:  handler,
+		lock:     lock,
+		nonEmpty: sync.NewCond(lock),
+		done:     make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			d.work()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(d.done)
+	}()
+
+	return d
+}
+
+// Submit queues a task for a worker. This call returns ErrQueueFull if
+// queueCap tasks are already waiting, and ErrDispatcherStopped once Stop
+// has been called.
+func (d *Dispatcher) Submit(task interface{}) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.stopping {
+		return ErrDispatcherStopped
+	}
+	if d.q.count >= d.capacity {
+		return ErrQueueFull
+	}
+	d.q.Add(task)
+	d.nonEmpty.Signal()
+	return nil
+}
+
+// Stop stops accepting tasks and waits for the workers to finish every task
+// submitted before it was called. If ctx is done first, Stop returns its
+// error; the workers still drain the remaining tasks in the background.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.lock.Lock()
+	d.stopping = true
+	d.nonEmpty.Broadcast()
+	d.lock.Unlock()
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runs tasks until the dispatcher is stopped and its queue is drained
+func (d *Dispatcher) work() {
+	for {
+		d.lock.Lock()
+		for d.q.count == 0 && !d.stopping {
+			d.nonEmpty.Wait()
+		}
+		task, err := d.q.Pop()
+		d.lock.Unlock()
+		if err != nil {
+			return
+		}
+
+		d.handler(task)
+	}
+}
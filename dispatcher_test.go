@@ -0,0 +1,76 @@
+//go:build go1.7
+// +build go1.7
+
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcher(t *testing.T) {
+	const tasks = 1000
+
+	var lock sync.Mutex
+	seen := make(map[int]int)
+	d := NewDispatcher(4, tasks, func(task interface{}) {
+		lock.Lock()
+		seen[task.(int)]++
+		lock.Unlock()
+	})
+
+	for i := 0; i < tasks; i++ {
+		if err := d.Submit(i); err != nil {
+			t.Fatal("unexpected error submitting task", i, err)
+		}
+	}
+	if err := d.Stop(context.Background()); err != nil {
+		t.Fatal("unexpected error stopping", err)
+	}
+
+	if len(seen) != tasks {
+		t.Error("processed", len(seen), "distinct tasks, expected", tasks)
+	}
+	for task, n := range seen {
+		if n != 1 {
+			t.Errorf("task %d processed %d times", task, n)
+		}
+	}
+
+	if err := d.Submit(0); err != ErrDispatcherStopped {
+		t.Error("should refuse tasks once stopped")
+	}
+}
+
+func TestDispatcherFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	d := NewDispatcher(1, 2, func(task interface{}) {
+		if task.(int) == 0 {
+			close(started)
+		}
+		<-release
+	})
+
+	d.Submit(0)
+	<-started
+	if d.Submit(1) != nil || d.Submit(2) != nil {
+		t.Fatal("should accept tasks up to capacity")
+	}
+	if err := d.Submit(3); err != ErrQueueFull {
+		t.Error("should error when queue is full")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := d.Stop(ctx); err != context.DeadlineExceeded {
+		t.Error("should time out while a task is blocked, got", err)
+	}
+
+	close(release)
+	if err := d.Stop(context.Background()); err != nil {
+		t.Error("unexpected error stopping", err)
+	}
+}
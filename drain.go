@@ -0,0 +1,61 @@
+package queue
+
+import (
+	"io"
+)
+
+// A flusher is a writer that buffers, such as a *bufio.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// DrainWriter writes the elements of the queue to w in order using write,
+// removing them as they are written, and returns the number removed. If w
+// has a Flush method, it is called after every batch elements and at the
+// end, and elements are only removed once they have been flushed; a batch
+// below 1 flushes only at the end.
+//
+// On the first error from write or Flush, DrainWriter stops and returns it.
+// Elements that were not successfully written and flushed stay at the front
+// of the queue, so nothing is lost, though elements written to a buffer that
+// then failed to flush may be written again by a later drain.
+func (q *Queue) DrainWriter(w io.Writer, write func(w io.Writer, elem interface{}) error, batch int) (int, error) {
+	f, buffered := w.(flusher)
+	if !buffered {
+		batch = 1
+	}
+
+	drained, pending := 0, 0
+	commit := func() error {
+		if buffered {
+			if err := f.Flush(); err != nil {
+				return err
+			}
+		}
+		for ; pending > 0; pending-- {
+			q.Remove()
+			drained++
+		}
+		return nil
+	}
+
+	for pending < q.count {
+		if err := write(w, q.buf[(q.head+pending)%len(q.buf)]); err != nil {
+			return drained, err
+		}
+		pending++
+
+		if pending == batch {
+			if err := commit(); err != nil {
+				return drained, err
+			}
+		}
+	}
+
+	if pending > 0 {
+		if err := commit(); err != nil {
+			return drained, err
+		}
+	}
+	return drained, nil
+}
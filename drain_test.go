@@ -0,0 +1,98 @@
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func writeLine(w io.Writer, elem interface{}) error {
+	_, err := fmt.Fprintln(w, elem)
+	return err
+}
+
+type flushCounter struct {
+	bytes.Buffer
+	flushes int
+	fail    bool
+}
+
+func (f *flushCounter) Flush() error {
+	if f.fail {
+		return errors.New("flush failed")
+	}
+	f.flushes++
+	return nil
+}
+
+func TestDrainWriter(t *testing.T) {
+	q := New()
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+	}
+
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	n, err := q.DrainWriter(bw, writeLine, 4)
+	if err != nil || n != 10 || q.Length() != 0 {
+		t.Fatal("drained", n, "leaving", q.Length(), err)
+	}
+	if buf.String() != "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n" {
+		t.Error("wrote", buf.String())
+	}
+}
+
+func TestDrainWriterBatches(t *testing.T) {
+	q := New()
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+	}
+
+	var f flushCounter
+	if n, err := q.DrainWriter(&f, writeLine, 4); err != nil || n != 10 {
+		t.Fatal("drained", n, err)
+	}
+	if f.flushes != 3 {
+		t.Error("flushed", f.flushes, "times")
+	}
+}
+
+func TestDrainWriterWriteError(t *testing.T) {
+	q := New()
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+	}
+
+	failAt := errors.New("write failed")
+	write := func(w io.Writer, elem interface{}) error {
+		if elem.(int) == 6 {
+			return failAt
+		}
+		return writeLine(w, elem)
+	}
+
+	var buf bytes.Buffer
+	n, err := q.DrainWriter(&buf, write, 4)
+	if err != failAt || n != 6 || q.Length() != 4 {
+		t.Error("drained", n, "leaving", q.Length(), err)
+	}
+	if e, _ := q.Peek(); e.(int) != 6 {
+		t.Error("failed element was not left at the front, head is", e)
+	}
+}
+
+func TestDrainWriterFlushError(t *testing.T) {
+	q := New()
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+	}
+
+	f := flushCounter{fail: true}
+	n, err := q.DrainWriter(&f, writeLine, 4)
+	if err == nil || n != 0 || q.Length() != 10 {
+		t.Error("drained", n, "leaving", q.Length(), err)
+	}
+}
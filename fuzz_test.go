@@ -0,0 +1,194 @@
+//go:build go1.18
+// +build go1.18
+
+package queue
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// fuzzConfigs are the options FuzzQueue constructs its queues with, chosen
+// by the config input, so that non-default resizing is covered too.
+var fuzzConfigs = [][]Option{
+	nil,
+	{WithGrowthFactor(1.5)},
+	{WithShrinkDivisor(8)},
+	{WithLazyNil(true)},
+	{WithGrowthFactor(1.25), WithShrinkDivisor(3), WithLazyNil(true)},
+}
+
+// a plain slice model of a queue, mirroring its ordering mode
+type fuzzModel struct {
+	elems   []interface{}
+	ordered bool
+}
+
+func (m *fuzzModel) add(elem interface{}) {
+	if !m.ordered {
+		m.elems = append(m.elems, elem)
+		return
+	}
+	i := sort.Search(len(m.elems), func(i int) bool { return lessKeyed(elem, m.elems[i]) })
+	m.elems = append(m.elems, nil)
+	copy(m.elems[i+1:], m.elems[i:])
+	m.elems[i] = elem
+}
+
+func (m *fuzzModel) check(t *testing.T, step int, q *Queue) {
+	if err := q.Validate(); err != nil {
+		t.Fatal("step", step, err)
+	}
+	if q.Length() != len(m.elems) {
+		t.Fatal("step", step, "queue has length", q.Length(), "expected", len(m.elems))
+	}
+	for i, want := range m.elems {
+		if e, _ := q.Get(i); e != want {
+			t.Fatal("step", step, "index", i, "had value", e, "expected", want)
+		}
+	}
+}
+
+// FuzzQueue applies a sequence of operations, one per input byte, to both a
+// Queue and a plain slice, checking that they agree after every step. The
+// operations include those that move elements around the buffer, such as
+// prepending, rotating, ordered inserts and removals from the middle, and a
+// second queue exchanges elements with the first through MoveN.
+func FuzzQueue(f *testing.F) {
+	f.Add(byte(0), []byte{0, 0, 0, 1, 2, 0, 3})
+	f.Add(byte(0), make([]byte, 3*minQueueLen))
+	f.Add(byte(1), []byte{0, 14, 27, 4, 43, 5, 6, 0, 13, 26, 7, 8, 6, 9, 10, 23, 11, 12})
+	f.Add(byte(4), []byte{56, 4, 17, 30, 43, 5, 18, 6, 1, 14, 27, 10, 23, 9, 22, 12, 2, 3})
+
+	f.Fuzz(func(t *testing.T, config byte, ops []byte) {
+		opts := fuzzConfigs[int(config)%len(fuzzConfigs)]
+		q, other := NewWithOptions(opts...), NewWithOptions(opts...)
+		model, otherModel := &fuzzModel{}, &fuzzModel{}
+
+		for step, op := range ops {
+			arg := int(op / 13)
+			elem := keyed{arg, step}
+
+			switch op % 13 {
+			case 0, 1:
+				q.Add(elem)
+				model.add(elem)
+			case 2:
+				e, err := q.Pop()
+				if len(model.elems) == 0 {
+					if err == nil {
+						t.Fatal("step", step, "popped", e, "from empty queue")
+					}
+					continue
+				}
+				if err != nil || e != model.elems[0] {
+					t.Fatal("step", step, "popped", e, "expected", model.elems[0])
+				}
+				model.elems = model.elems[1:]
+			case 3:
+				err := q.Remove()
+				if (err == nil) != (len(model.elems) > 0) {
+					t.Fatal("step", step, "remove returned", err)
+				}
+				if len(model.elems) > 0 {
+					model.elems = model.elems[1:]
+				}
+			case 4:
+				elems := make([]interface{}, arg%4)
+				for i := range elems {
+					elems[i] = keyed{arg, -step*4 - i}
+				}
+				q.PrependSlice(elems)
+				model.elems = append(elems[:len(elems):len(elems)], model.elems...)
+			case 5:
+				q.RotateNormalized(arg - 10)
+				if n := len(model.elems); n > 0 {
+					k := ((arg-10)%n + n) % n
+					model.elems = append(model.elems[k:len(model.elems):len(model.elems)], model.elems[:k]...)
+				}
+			case 6:
+				if model.ordered {
+					q.DisableOrdering()
+				} else {
+					q.EnableOrdering(lessKeyed)
+					sort.SliceStable(model.elems, func(i, j int) bool {
+						return lessKeyed(model.elems[i], model.elems[j])
+					})
+				}
+				model.ordered = !model.ordered
+			case 7:
+				for it := q.RemoveIter(); it.Next(); {
+					if it.Value().(keyed).key%2 == arg%2 {
+						it.Remove()
+					}
+				}
+				var kept []interface{}
+				for _, e := range model.elems {
+					if e.(keyed).key%2 != arg%2 {
+						kept = append(kept, e)
+					}
+				}
+				model.elems = kept
+			case 8:
+				drained := q.DrainWhere(func(e interface{}) bool { return e.(keyed).key == arg })
+				var kept, want []interface{}
+				for _, e := range model.elems {
+					if e.(keyed).key == arg {
+						want = append(want, e)
+					} else {
+						kept = append(kept, e)
+					}
+				}
+				if len(drained) != len(want) {
+					t.Fatal("step", step, "drained", len(drained), "elements, expected", len(want))
+				}
+				for i := range want {
+					if drained[i] != want[i] {
+						t.Fatal("step", step, "drained", drained[i], "expected", want[i])
+					}
+				}
+				model.elems = kept
+			case 9:
+				e, err := q.PopRandom(rand.New(rand.NewSource(int64(step))))
+				if len(model.elems) == 0 {
+					if err == nil {
+						t.Fatal("step", step, "popped", e, "from empty queue")
+					}
+					continue
+				}
+				i := rand.New(rand.NewSource(int64(step))).Intn(len(model.elems))
+				model.elems[0], model.elems[i] = model.elems[i], model.elems[0]
+				if err != nil || e != model.elems[0] {
+					t.Fatal("step", step, "randomly popped", e, "expected", model.elems[0])
+				}
+				model.elems = model.elems[1:]
+			case 10:
+				dst, src, dstModel, srcModel := other, q, otherModel, model
+				if arg%2 == 1 {
+					dst, src, dstModel, srcModel = q, other, model, otherModel
+				}
+				n := MoveN(dst, src, arg/2)
+				if want := len(srcModel.elems); n != arg/2 && n != want {
+					t.Fatal("step", step, "moved", n, "of", want, "elements")
+				}
+				for _, e := range srcModel.elems[:n] {
+					dstModel.add(e)
+				}
+				srcModel.elems = srcModel.elems[n:]
+			case 11:
+				elems := make([]interface{}, arg%8)
+				for i := range elems {
+					elems[i] = keyed{arg, step*8 + i}
+				}
+				q.ReplaceAll(elems)
+				model.elems = append([]interface{}(nil), elems...)
+			case 12:
+				q.Shrink()
+			}
+
+			model.check(t, step, q)
+			otherModel.check(t, step, other)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package queue
+
+// A RemoveIterator walks a queue in order and can remove the current
+// element as it goes:
+//
+//	for it := q.RemoveIter(); it.Next(); {
+//		if cond(it.Value()) {
+//			it.Remove()
+//		}
+//	}
+//
+// Modifying the queue other than through the iterator invalidates it.
+type RemoveIterator struct {
+	q               *Queue
+	next, cur, mods int
+}
+
+// RemoveIter returns a new RemoveIterator positioned before the head of the
+// queue.
+func (q *Queue) RemoveIter() *RemoveIterator {
+	return &RemoveIterator{q: q, cur: -1, mods: q.mods}
+}
+
+// Next advances the iterator to the next element, returning false once every
+// element has been visited.
+func (it *RemoveIterator) Next() bool {
+	it.q.checkMods(it.mods)
+	if it.next >= it.q.count {
+		it.cur = -1
+		return false
+	}
+	it.cur = it.next
+	it.next++
+	return true
+}
+
+// Value returns the current element, or nil if there is none because Next
+// has not been called, has returned false, or the element was removed.
+func (it *RemoveIterator) Value() interface{} {
+	it.q.checkMods(it.mods)
+	if it.cur < 0 {
+		return nil
+	}
+	return it.q.buf[(it.q.head+it.cur)%len(it.q.buf)]
+}
+
+// Remove removes the current element from the queue. The next call to Next
+// moves on to the element that followed it. Remove does nothing if there is
+// no current element.
+func (it *RemoveIterator) Remove() {
+	it.q.checkMods(it.mods)
+	if it.cur < 0 {
+		return
+	}
+	if it.q.recording() {
+		it.q.record("RemoveIterator.Remove", it.cur)
+	}
+
+	it.q.removeAt(it.cur)
+	it.mods = it.q.mods
+	it.next = it.cur
+	it.cur = -1
+}
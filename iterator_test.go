@@ -0,0 +1,60 @@
+package queue
+
+import "testing"
+
+func TestRemoveIterator(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+	}
+
+	visited := 0
+	for it := q.RemoveIter(); it.Next(); {
+		if it.Value().(int) != visited {
+			t.Fatal("visit", visited, "had value", it.Value())
+		}
+		if visited%3 != 0 {
+			it.Remove()
+			if it.Value() != nil {
+				t.Error("removed element still has value", it.Value())
+			}
+		}
+		visited++
+
+		if err := q.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if visited != 1000 || q.Length() != 334 {
+		t.Fatal("visited", visited, "and kept", q.Length())
+	}
+	for i := 0; i < q.Length(); i++ {
+		if e, _ := q.Get(i); e.(int) != i*3 {
+			t.Errorf("index %d doesn't contain %d", i, i*3)
+		}
+	}
+}
+
+func TestRemoveIteratorRemoveAll(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	it := q.RemoveIter()
+	it.Remove()
+	if q.Length() != 100 {
+		t.Error("remove before next changed length to", q.Length())
+	}
+	for it.Next() {
+		it.Remove()
+		it.Remove()
+	}
+
+	if q.Length() != 0 || len(q.buf) != minQueueLen {
+		t.Error("emptied queue has length", q.Length(), "and capacity", len(q.buf))
+	}
+}
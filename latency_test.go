@@ -0,0 +1,33 @@
+//go:build go1.13
+// +build go1.13
+
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+// benchmarkDrainLatency fills a queue and then drains it, reporting the
+// slowest single Remove, which is dominated by the copies made when the
+// buffer shrinks.
+func benchmarkDrainLatency(b *testing.B, opts ...Option) {
+	var worst time.Duration
+	for i := 0; i < b.N; i++ {
+		q := NewWithOptions(opts...)
+		for j := 0; j < 1<<20; j++ {
+			q.Add(nil)
+		}
+		for q.Length() > 0 {
+			start := time.Now()
+			q.Remove()
+			if d := time.Since(start); d > worst {
+				worst = d
+			}
+		}
+	}
+	b.ReportMetric(float64(worst.Nanoseconds()), "max-ns/remove")
+}
+
+func BenchmarkDrainLatencyDefault(b *testing.B) { benchmarkDrainLatency(b) }
+func BenchmarkDrainLatencyShrink8(b *testing.B) { benchmarkDrainLatency(b, WithShrinkDivisor(8)) }
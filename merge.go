@@ -0,0 +1,79 @@
+package queue
+
+// MergeSorted merges two queues that are already sorted by less into a new
+// sorted queue. Where elements compare equal, those from a come first.
+// Neither input is modified.
+func MergeSorted(a, b *Queue, less func(x, y interface{}) bool) *Queue {
+	merged := newWithCapacity(a.count + b.count)
+
+	i, j := 0, 0
+	for i < a.count && j < b.count {
+		x := a.buf[(a.head+i)%len(a.buf)]
+		y := b.buf[(b.head+j)%len(b.buf)]
+		if less(y, x) {
+			merged.Add(y)
+			j++
+		} else {
+			merged.Add(x)
+			i++
+		}
+	}
+	for ; i < a.count; i++ {
+		merged.Add(a.buf[(a.head+i)%len(a.buf)])
+	}
+	for ; j < b.count; j++ {
+		merged.Add(b.buf[(b.head+j)%len(b.buf)])
+	}
+
+	return merged
+}
+
+// Interleave returns a new queue built by taking one element from each of
+// queues in turn, in order, skipping any that have run out, until all are
+// exhausted. None of the inputs are modified.
+func Interleave(queues ...*Queue) *Queue {
+	total, longest := 0, 0
+	for _, q := range queues {
+		total += q.count
+		if q.count > longest {
+			longest = q.count
+		}
+	}
+
+	merged := newWithCapacity(total)
+	for i := 0; i < longest; i++ {
+		for _, q := range queues {
+			if i < q.count {
+				merged.Add(q.buf[(q.head+i)%len(q.buf)])
+			}
+		}
+	}
+
+	return merged
+}
+
+// MoveN removes up to n elements from the head of src and adds them, in
+// order, to the end of dst, returning how many were moved. dst grows at most
+// once to fit them, and src only checks whether to shrink once at the end.
+func MoveN(dst, src *Queue, n int) int {
+	if n > src.count {
+		n = src.count
+	}
+	if n <= 0 {
+		return 0
+	}
+	if src.recording() {
+		src.record("MoveN", n)
+	}
+	if dst.recording() && dst != src {
+		dst.record("MoveN", n)
+	}
+
+	dst.grow(n)
+	for i := 0; i < n; i++ {
+		dst.add(src.removeHead())
+	}
+	src.maybeShrink()
+
+	return n
+}
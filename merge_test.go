@@ -0,0 +1,142 @@
+package queue
+
+import "testing"
+
+func TestMergeSorted(t *testing.T) {
+	a, b := New(), New()
+
+	for i := 0; i < 1000; i++ {
+		if i%3 == 0 {
+			a.Add(i)
+		} else {
+			b.Add(i)
+		}
+	}
+
+	merged := MergeSorted(a, b, func(x, y interface{}) bool { return x.(int) < y.(int) })
+	if merged.Length() != 1000 || len(merged.buf) != 1000 {
+		t.Fatal("merged queue has length", merged.Length(), "and capacity", len(merged.buf))
+	}
+	for i := 0; i < 1000; i++ {
+		if e, _ := merged.Get(i); e.(int) != i {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+	}
+	if a.Length()+b.Length() != 1000 {
+		t.Error("merging modified its inputs")
+	}
+}
+
+func TestMergeSortedStable(t *testing.T) {
+	a, b := New(), New()
+
+	a.Add(keyed{1, 0})
+	b.Add(keyed{1, 1})
+	a.Add(keyed{2, 2})
+
+	merged := MergeSorted(a, b, lessKeyed)
+	for i := 0; i < 3; i++ {
+		if e, _ := merged.Get(i); e.(keyed).seq != i {
+			t.Errorf("index %d contains %v", i, e)
+		}
+	}
+
+	if MergeSorted(New(), New(), lessKeyed).Length() != 0 {
+		t.Error("merging empty queues should be empty")
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	a, b, c := New(), New(), New()
+
+	for i := 0; i < 3; i++ {
+		a.Add("a")
+	}
+	b.Add("b")
+	for i := 0; i < 2; i++ {
+		c.Add("c")
+	}
+
+	merged := Interleave(a, New(), b, c)
+	want := "abcaca"
+	if merged.Length() != len(want) {
+		t.Fatal("interleaved queue has length", merged.Length())
+	}
+	for i := range want {
+		if e, _ := merged.Get(i); e.(string) != want[i:i+1] {
+			t.Errorf("index %d contains %v", i, e)
+		}
+	}
+	if a.Length() != 3 || b.Length() != 1 || c.Length() != 2 {
+		t.Error("interleaving modified its inputs")
+	}
+
+	if Interleave().Length() != 0 {
+		t.Error("interleaving nothing should be empty")
+	}
+}
+
+func TestMoveN(t *testing.T) {
+	src, dst := New(), New()
+
+	for i := 0; i < 1000; i++ {
+		src.Add(i)
+	}
+	dst.Add(-1)
+
+	if n := MoveN(dst, src, 600); n != 600 {
+		t.Error("moved", n, "elements")
+	}
+	if src.Length() != 400 || dst.Length() != 601 {
+		t.Fatal("lengths after move were", src.Length(), dst.Length())
+	}
+	for i := 0; i < 600; i++ {
+		if e, _ := dst.Get(i + 1); e.(int) != i {
+			t.Errorf("destination index %d doesn't contain %d", i+1, i)
+		}
+	}
+	if e, _ := src.Peek(); e.(int) != 600 {
+		t.Error("source head is", e)
+	}
+
+	if n := MoveN(dst, src, 1000); n != 400 || src.Length() != 0 {
+		t.Error("moved", n, "elements, leaving", src.Length())
+	}
+	if len(src.buf) != minQueueLen {
+		t.Error("emptied source has capacity", len(src.buf))
+	}
+	if MoveN(dst, src, 10) != 0 {
+		t.Error("moved elements from empty queue")
+	}
+
+	for _, q := range []*Queue{src, dst} {
+		if err := q.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestMoveNKeepsElements(t *testing.T) {
+	src, dst := NewWithOptions(WithIndex(func(elem interface{}) interface{} {
+		return elem
+	})), New()
+
+	reclaimed := 0
+	src.SetReclaimFunc(func(elem interface{}) { reclaimed++ })
+	for i := 0; i < 3; i++ {
+		src.Add(i)
+	}
+
+	MoveN(dst, src, 2)
+	if reclaimed != 0 {
+		t.Error("moving reclaimed", reclaimed, "elements still in use")
+	}
+	if src.ContainsKey(0) || src.ContainsKey(1) || !src.ContainsKey(2) {
+		t.Error("source index not updated for moved elements")
+	}
+
+	src.Remove()
+	if reclaimed != 1 {
+		t.Error("removing reclaimed", reclaimed, "elements")
+	}
+}
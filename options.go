@@ -0,0 +1,97 @@
+package queue
+
+// An Option configures a Queue constructed with NewWithOptions.
+type Option func(*Queue)
+
+// NewWithOptions constructs and returns a new Queue configured by opts.
+func NewWithOptions(opts ...Option) *Queue {
+	q := New()
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// WithGrowthFactor makes the queue grow its buffer to f times its contents
+// when full, instead of doubling. The queue shrinks once its contents drop to
+// 1/f² of the buffer, so it does not immediately grow again. Smaller factors
+// waste less memory on large queues at the cost of more frequent copies. It
+// panics unless f is greater than 1.
+func WithGrowthFactor(f float64) Option {
+	if !(f > 1) {
+		panic("queue: WithGrowthFactor() called with factor not greater than 1")
+	}
+	return func(q *Queue) {
+		q.growth = f
+	}
+}
+
+// WithShrinkDivisor makes Remove shrink the buffer only once the queue is at
+// most 1/d full, rather than at 1/f² for growth factor f (a quarter, by
+// default). A larger divisor shrinks less often and copies fewer elements when
+// it does, smoothing out latency while draining, but holds on to more memory.
+// A divisor no larger than f² has no effect, since the queue never shrinks
+// before it is at most 1/f² full. It panics if d is less than 2.
+func WithShrinkDivisor(d int) Option {
+	if d < 2 {
+		panic("queue: WithShrinkDivisor() called with divisor less than 2")
+	}
+	return func(q *Queue) {
+		q.shrinkDiv = d
+	}
+}
+
+// WithLazyNil, when enabled, makes Remove leave the removed element in its
+// slot instead of clearing it, saving a memory write per removal. Removed
+// elements stay reachable, and so are not garbage collected, until the slot
+// is reused, the buffer is resized, or SweepDead is called.
+func WithLazyNil(enabled bool) Option {
+	return func(q *Queue) {
+		q.ensureHooks().lazyNil = enabled
+	}
+}
+
+// WithIndex makes the queue maintain a count of its elements by the key
+// keyFn returns for them, so that ContainsKey and CountKey run in O(1). Keys
+// must be valid map keys. This costs a map entry per distinct key and a call
+// to keyFn and a map update on every addition and removal.
+func WithIndex(keyFn func(elem interface{}) interface{}) Option {
+	return func(q *Queue) {
+		h := q.ensureHooks()
+		h.keyFn = keyFn
+		h.index = make(map[interface{}]int)
+	}
+}
+
+// WithRecorder makes the queue keep a log of its last n mutating operations,
+// retrievable with OperationLog, to help reconstruct how it reached its
+// current state. Operations made internally, such as evictions, are implied
+// by the recorded call rather than logged separately. Recording costs an
+// allocation per operation, so it is off by default. It panics if n is less
+// than 1.
+func WithRecorder(n int) Option {
+	if n < 1 {
+		panic("queue: WithRecorder() called with size less than 1")
+	}
+	return func(q *Queue) {
+		q.ensureHooks().recorder = NewWindow(n)
+	}
+}
+
+// WithCodec sets the Codec used to encode and decode elements when the queue
+// is written with WriteTo or read with ReadFrom.
+func WithCodec(c Codec) Option {
+	return func(q *Queue) {
+		q.codec = c
+	}
+}
+
+// WithModCheck makes iteration fail fast: Cursor, ForEachLimit and
+// RemoveIterator panic if the queue is modified other than through the
+// iterator itself while they are in use, rather than silently skipping or
+// repeating elements.
+func WithModCheck() Option {
+	return func(q *Queue) {
+		q.ensureHooks().modCheck = true
+	}
+}
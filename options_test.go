@@ -0,0 +1,238 @@
+package queue
+
+import "testing"
+
+func TestGrowthFactor(t *testing.T) {
+	q := NewWithOptions(WithGrowthFactor(1.25))
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+		if err := q.Validate(); err != nil {
+			t.Fatal("adding:", err)
+		}
+	}
+	if len(q.buf) >= 1250 || len(q.buf) < 1000 {
+		t.Error("queue of 1000 elements grown by 1.25 has capacity", len(q.buf))
+	}
+
+	for i := 0; i < 1000; i++ {
+		if e, _ := q.Pop(); e.(int) != i {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+		if err := q.Validate(); err != nil {
+			t.Fatal("removing:", err)
+		}
+	}
+	if len(q.buf) != minQueueLen {
+		t.Error("drained queue has capacity", len(q.buf))
+	}
+}
+
+func TestGrowthFactorInvalid(t *testing.T) {
+	for _, f := range []float64{1, 0.5, -2} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("should panic with growth factor", f)
+				}
+			}()
+			WithGrowthFactor(f)
+		}()
+	}
+}
+
+func TestShrinkDivisor(t *testing.T) {
+	q := NewWithOptions(WithShrinkDivisor(8))
+
+	for i := 0; i < 1024; i++ {
+		q.Add(i)
+	}
+	for i := 0; i < 1024-256; i++ {
+		q.Remove()
+	}
+	if len(q.buf) != 1024 {
+		t.Error("queue a quarter full shrank to", len(q.buf))
+	}
+
+	for i := 0; i < 128; i++ {
+		q.Remove()
+	}
+	if len(q.buf) != 256 {
+		t.Error("queue an eighth full has capacity", len(q.buf))
+	}
+
+	for q.Length() > 0 {
+		if e, _ := q.Pop(); e.(int) != 1024-q.Length()-1 {
+			t.Error("popped", e, "with", q.Length(), "remaining")
+		}
+		if err := q.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(q.buf) != minQueueLen {
+		t.Error("drained queue has capacity", len(q.buf))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("should panic with divisor 1")
+		}
+	}()
+	WithShrinkDivisor(1)
+}
+
+func TestShrinkDivisorBelowGrowth(t *testing.T) {
+	for _, d := range []int{2, 3, 4} {
+		q := NewWithOptions(WithShrinkDivisor(d))
+		for i := 0; i < 4096; i++ {
+			q.Add(i)
+		}
+
+		resizes := 0
+		for q.Length() > 0 {
+			n := len(q.buf)
+			q.Remove()
+			if len(q.buf) != n {
+				resizes++
+			}
+		}
+		if resizes > 8 {
+			t.Error("draining with divisor", d, "resized", resizes, "times")
+		}
+	}
+}
+
+func TestLazyNil(t *testing.T) {
+	q := NewWithOptions(WithLazyNil(true))
+
+	elems := make([]*int, 10)
+	for i := range elems {
+		elems[i] = new(int)
+		q.Add(elems[i])
+	}
+	for i := 0; i < 5; i++ {
+		q.Remove()
+	}
+
+	for i := 0; i < 5; i++ {
+		if q.buf[i] != elems[i] {
+			t.Error("removed slot", i, "was cleared")
+		}
+	}
+	if err := q.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	q.SweepDead()
+	for i := 0; i < 5; i++ {
+		if q.buf[i] != nil {
+			t.Error("swept slot", i, "still holds", q.buf[i])
+		}
+	}
+	for i := 5; i < 10; i++ {
+		if e, _ := q.Get(i - 5); e != elems[i] {
+			t.Errorf("index %d lost its element after sweeping", i-5)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	q := NewWithOptions(WithIndex(func(elem interface{}) interface{} {
+		return elem.(int) % 10
+	}))
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+	if !q.ContainsKey(3) || q.CountKey(3) != 10 || q.ContainsKey(10) {
+		t.Error("key 3 has count", q.CountKey(3))
+	}
+
+	for i := 0; i < 5; i++ {
+		q.Pop()
+	}
+	q.PrependSlice([]interface{}{1, 2})
+	if q.CountKey(0) != 9 || q.CountKey(1) != 10 || q.CountKey(9) != 10 {
+		t.Error("keys have counts", q.CountKey(0), q.CountKey(1), q.CountKey(9))
+	}
+
+	q.ReplaceAll([]interface{}{7, 17})
+	if q.ContainsKey(0) || q.CountKey(7) != 2 || len(q.hooks.index) != 1 {
+		t.Error("replaced queue has index", q.hooks.index)
+	}
+
+	for q.Length() > 0 {
+		q.Remove()
+	}
+	if len(q.hooks.index) != 0 {
+		t.Error("emptied queue has index", q.hooks.index)
+	}
+
+	if New().ContainsKey(1) || New().CountKey(1) != 0 {
+		t.Error("queue without index should contain no keys")
+	}
+}
+
+func expectModPanic(t *testing.T, name string, fn func()) {
+	defer func() {
+		if recover() == nil {
+			t.Error(name, "should panic when modified during iteration")
+		}
+	}()
+	fn()
+}
+
+func TestModCheck(t *testing.T) {
+	q := NewWithOptions(WithModCheck())
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+	}
+
+	expectModPanic(t, "ForEachLimit", func() {
+		q.ForEachLimit(5, func(interface{}) { q.Add(0) })
+	})
+
+	expectModPanic(t, "Cursor", func() {
+		c := q.Cursor()
+		c.Next()
+		q.Remove()
+		c.Next()
+	})
+
+	expectModPanic(t, "RemoveIterator", func() {
+		it := q.RemoveIter()
+		it.Next()
+		q.RotateNormalized(1)
+		it.Next()
+	})
+
+	// modifying through the iterator, or resetting a cursor, is fine
+	it := q.RemoveIter()
+	for it.Next() {
+		it.Remove()
+	}
+	c := q.Cursor()
+	q.Add(1)
+	c.Reset()
+	if e, ok := c.Next(); !ok || e.(int) != 1 {
+		t.Error("reset cursor returned", e)
+	}
+
+	// without the option, nothing panics
+	q = New()
+	q.Add(1)
+	q.ForEachLimit(1, func(interface{}) { q.Add(0) })
+}
+
+func benchmarkGrowthFactor(b *testing.B, f float64) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q := NewWithOptions(WithGrowthFactor(f))
+		for j := 0; j < 100000; j++ {
+			q.Add(nil)
+		}
+	}
+}
+
+func BenchmarkGrowthFactor2(b *testing.B)    { benchmarkGrowthFactor(b, 2) }
+func BenchmarkGrowthFactor1_25(b *testing.B) { benchmarkGrowthFactor(b, 1.25) }
@@ -0,0 +1,50 @@
+package queue
+
+// An OrderedQueue is a Queue that keeps its elements sorted by a less
+// function, so that Peek and Pop always return the smallest element. Add
+// finds its position with a binary search but must shift elements to insert,
+// so it costs O(n), while Pop stays O(1). Elements that compare equal keep
+// the order in which they were added.
+type OrderedQueue struct {
+	q *Queue
+}
+
+// NewOrdered constructs and returns a new OrderedQueue sorted by less.
+func NewOrdered(less func(a, b interface{}) bool) *OrderedQueue {
+	q := New()
+	q.EnableOrdering(less)
+	return &OrderedQueue{q: q}
+}
+
+// Length returns the number of elements currently stored in the queue.
+func (o *OrderedQueue) Length() int {
+	return o.q.Length()
+}
+
+// Add puts an element in the queue after every element not greater than it.
+func (o *OrderedQueue) Add(elem interface{}) {
+	o.q.Add(elem)
+}
+
+// Peek returns the smallest element in the queue. This call returns
+// ErrEmptyQueue if the queue is empty.
+func (o *OrderedQueue) Peek() (interface{}, error) {
+	return o.q.Peek()
+}
+
+// Get returns the element at index i in sorted order. If the index is
+// invalid, the call returns ErrOutOfRange.
+func (o *OrderedQueue) Get(i int) (interface{}, error) {
+	return o.q.Get(i)
+}
+
+// Pop removes and returns the smallest element in the queue.
+func (o *OrderedQueue) Pop() (interface{}, error) {
+	return o.q.Pop()
+}
+
+// Remove removes the smallest element from the queue. This call returns
+// ErrEmptyQueue if the queue is empty.
+func (o *OrderedQueue) Remove() error {
+	return o.q.Remove()
+}
@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"math/rand"
+	"testing"
+)
+
+type keyed struct {
+	key, seq int
+}
+
+func lessKeyed(a, b interface{}) bool {
+	return a.(keyed).key < b.(keyed).key
+}
+
+func TestOrderedQueueSorted(t *testing.T) {
+	q := NewOrdered(lessKeyed)
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		q.Add(keyed{r.Intn(50), i})
+		if err := q.q.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prev := keyed{-1, -1}
+	for i := 0; i < 1000; i++ {
+		e, err := q.Pop()
+		if err != nil {
+			t.Fatal(err)
+		}
+		k := e.(keyed)
+		if k.key < prev.key || (k.key == prev.key && k.seq < prev.seq) {
+			t.Errorf("popped %v after %v", k, prev)
+		}
+		prev = k
+	}
+
+	if _, err := q.Pop(); err != ErrEmptyQueue {
+		t.Error("should error when popping empty queue")
+	}
+}
+
+func TestOrderedQueueInsertPositions(t *testing.T) {
+	q := NewOrdered(func(a, b interface{}) bool { return a.(int) < b.(int) })
+
+	for _, e := range []int{5, 1, 9, 3, 7, 0, 10, 2, 8, 4, 6} {
+		q.Add(e)
+	}
+	for i := 0; i <= 10; i++ {
+		if e, _ := q.Get(i); e.(int) != i {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+	}
+}
+
+func TestEnableOrdering(t *testing.T) {
+	q := New()
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		q.Add(keyed{r.Intn(50), i})
+	}
+	q.EnableOrdering(lessKeyed)
+	for i := 500; i < 1000; i++ {
+		q.Add(keyed{r.Intn(50), i})
+	}
+	if err := q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := keyed{-1, -1}
+	for i := 0; i < 1000; i++ {
+		e, _ := q.Get(i)
+		k := e.(keyed)
+		if k.key < prev.key || (k.key == prev.key && k.seq < prev.seq) {
+			t.Errorf("index %d contains %v after %v", i, k, prev)
+		}
+		prev = k
+	}
+
+	q.DisableOrdering()
+	q.Add(keyed{-1, 1000})
+	if e, _ := q.Get(1000); e.(keyed).key != -1 {
+		t.Error("add after disabling ordering didn't append, got", e)
+	}
+}
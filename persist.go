@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The persisted format is the magic bytes, a version byte, the element count
+// as a uvarint, and then each element's encoding prefixed by its length as a
+// uvarint.
+const persistVersion = 1
+
+var persistMagic = []byte("GOQU")
+
+var (
+	// ErrNoCodec is returned when writing or reading a queue without a Codec.
+	ErrNoCodec = errors.New("queue: no codec configured")
+	// ErrBadMagic is returned when reading data that is not a persisted queue.
+	ErrBadMagic = errors.New("queue: bad magic, not a persisted queue")
+)
+
+// A Codec converts elements to and from bytes for WriteTo and ReadFrom.
+type Codec interface {
+	Encode(elem interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// WriteTo writes the elements of the queue to w in order, encoded with the
+// Codec set by WithCodec, and returns the number of bytes written. Nothing is
+// written if an element fails to encode.
+func (q *Queue) WriteTo(w io.Writer) (int64, error) {
+	if q.codec == nil {
+		return 0, ErrNoCodec
+	}
+
+	var buf bytes.Buffer
+	var varint [binary.MaxVarintLen64]byte
+
+	buf.Write(persistMagic)
+	buf.WriteByte(persistVersion)
+	buf.Write(varint[:binary.PutUvarint(varint[:], uint64(q.count))])
+
+	for i := 0; i < q.count; i++ {
+		data, err := q.codec.Encode(q.buf[(q.head+i)%len(q.buf)])
+		if err != nil {
+			return 0, err
+		}
+		buf.Write(varint[:binary.PutUvarint(varint[:], uint64(len(data)))])
+		buf.Write(data)
+	}
+
+	return buf.WriteTo(w)
+}
+
+// ReadFrom reads a queue written by WriteTo from r and returns it as a new
+// queue configured by opts, which must include WithCodec. If r is not an
+// io.ByteReader it is buffered, and may be read past the end of the queue.
+func ReadFrom(r io.Reader, opts ...Option) (*Queue, error) {
+	q := NewWithOptions(opts...)
+	if q.codec == nil {
+		return nil, ErrNoCodec
+	}
+
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		buffered := bufio.NewReader(r)
+		r, br = buffered, buffered
+	}
+
+	header := make([]byte, len(persistMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, truncated(err)
+	}
+	if !bytes.Equal(header[:len(persistMagic)], persistMagic) {
+		return nil, ErrBadMagic
+	}
+	if v := header[len(persistMagic)]; v != persistVersion {
+		return nil, fmt.Errorf("queue: unsupported persisted queue version %d", v)
+	}
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, truncated(err)
+	}
+
+	var data bytes.Buffer
+	for i := uint64(0); i < count; i++ {
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, truncated(err)
+		}
+
+		data.Reset()
+		if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+			return nil, truncated(err)
+		}
+
+		elem, err := q.codec.Decode(data.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		q.Add(elem)
+	}
+
+	return q, nil
+}
+
+// reports an EOF partway through a persisted queue as unexpected
+func truncated(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
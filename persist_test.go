@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+)
+
+type intCodec struct{}
+
+func (intCodec) Encode(elem interface{}) ([]byte, error) {
+	return []byte(strconv.Itoa(elem.(int))), nil
+}
+
+func (intCodec) Decode(data []byte) (interface{}, error) {
+	return strconv.Atoi(string(data))
+}
+
+func persisted(t *testing.T, n int) []byte {
+	q := NewWithOptions(WithCodec(intCodec{}))
+	for i := 0; i < n; i++ {
+		q.Add(i)
+	}
+
+	var buf bytes.Buffer
+	written, err := q.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != int64(buf.Len()) {
+		t.Error("wrote", buf.Len(), "bytes but reported", written)
+	}
+	return buf.Bytes()
+}
+
+func TestPersistRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 1, 1000} {
+		q, err := ReadFrom(bytes.NewReader(persisted(t, n)), WithCodec(intCodec{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Length() != n {
+			t.Fatal("read queue has length", q.Length(), "expected", n)
+		}
+		for i := 0; i < n; i++ {
+			if e, _ := q.Get(i); e.(int) != i {
+				t.Errorf("index %d doesn't contain %d", i, i)
+			}
+		}
+	}
+}
+
+func TestPersistBadMagic(t *testing.T) {
+	data := persisted(t, 10)
+	data[0] = 'X'
+
+	if _, err := ReadFrom(bytes.NewReader(data), WithCodec(intCodec{})); err != ErrBadMagic {
+		t.Error("should error with bad magic, got", err)
+	}
+
+	data[0] = persistMagic[0]
+	data[len(persistMagic)] = persistVersion + 1
+	if _, err := ReadFrom(bytes.NewReader(data), WithCodec(intCodec{})); err == nil {
+		t.Error("should error with unknown version")
+	}
+}
+
+func TestPersistTruncated(t *testing.T) {
+	data := persisted(t, 10)
+
+	for _, n := range []int{0, 3, len(persistMagic) + 1, len(data) - 1} {
+		// read through io.Reader only, to exercise the buffered path
+		r := struct{ io.Reader }{bytes.NewReader(data[:n])}
+		if _, err := ReadFrom(r, WithCodec(intCodec{})); err != io.ErrUnexpectedEOF {
+			t.Error("truncated at", n, "gave", err)
+		}
+	}
+}
+
+func TestPersistNoCodec(t *testing.T) {
+	if _, err := New().WriteTo(&bytes.Buffer{}); err != ErrNoCodec {
+		t.Error("writing without codec gave", err)
+	}
+	if _, err := ReadFrom(&bytes.Buffer{}); err != ErrNoCodec {
+		t.Error("reading without codec gave", err)
+	}
+}
@@ -0,0 +1,78 @@
+//go:build go1.3
+// +build go1.3
+
+package queue
+
+import (
+	"sync"
+)
+
+// poolWeight is the weight given to each new observation in the Pool's
+// moving average of queue lengths.
+const poolWeight = 0.125
+
+// A Pool recycles queues to avoid reallocating their buffers, and tunes
+// itself to the workload: it keeps an exponentially weighted moving average
+// of the length of queues as they are Put back, and Get hands out queues
+// with room for about that many elements, up to a maximum. It is safe for
+// concurrent use.
+type Pool struct {
+	pool        sync.Pool
+	lock        sync.Mutex
+	estimate    float64
+	maxCapacity int
+}
+
+// NewPool constructs and returns a new Pool that pre-sizes the queues it
+// hands out to no more than maxCapacity elements, or the minimum size of a new
+// queue if that is larger. Queues that grew beyond that limit are dropped by
+// Put rather than kept with their large buffers.
+func NewPool(maxCapacity int) *Pool {
+	return &Pool{maxCapacity: maxCapacity}
+}
+
+// Get returns an empty queue with room for at least Estimate elements.
+func (p *Pool) Get() *Queue {
+	q, _ := p.pool.Get().(*Queue)
+	if q == nil {
+		q = New()
+	}
+
+	if n := p.Estimate(); n > len(q.buf) {
+		q.resizeTo(n)
+	}
+	return q
+}
+
+// Put records the queue's current length in the moving average, then empties
+// it and returns it to the pool, unless its buffer has grown beyond the
+// maximum capacity. Put queues at their peak, before draining them, for the
+// estimate to reflect the space they needed. The queue must not be used after
+// Put, and should have come from Get.
+func (p *Pool) Put(q *Queue) {
+	p.lock.Lock()
+	p.estimate += (float64(q.count) - p.estimate) * poolWeight
+	p.lock.Unlock()
+
+	q.ReplaceAll(nil)
+	if len(q.buf) <= p.maxCapacity || len(q.buf) <= minQueueLen {
+		p.pool.Put(q)
+	}
+}
+
+// Estimate returns the capacity Get currently pre-sizes queues to: the
+// moving average of lengths seen by Put, rounded up and capped at the
+// maximum capacity given to NewPool.
+func (p *Pool) Estimate() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	n := int(p.estimate)
+	if float64(n) < p.estimate {
+		n++
+	}
+	if n > p.maxCapacity {
+		n = p.maxCapacity
+	}
+	return n
+}
@@ -0,0 +1,67 @@
+//go:build go1.3
+// +build go1.3
+
+package queue
+
+import "testing"
+
+func TestPoolEstimate(t *testing.T) {
+	p := NewPool(1000)
+
+	if p.Estimate() != 0 {
+		t.Error("new pool has estimate", p.Estimate())
+	}
+
+	for i := 0; i < 100; i++ {
+		q := p.Get()
+		if q.Length() != 0 {
+			t.Fatal("pool returned queue of length", q.Length())
+		}
+		for j := 0; j < 200; j++ {
+			q.Add(j)
+		}
+		p.Put(q)
+	}
+
+	if e := p.Estimate(); e < 190 || e > 200 {
+		t.Error("pool of 200-element queues has estimate", e)
+	}
+	if q := p.Get(); len(q.buf) < p.Estimate() {
+		t.Error("pool returned queue with capacity", len(q.buf))
+	}
+}
+
+func TestPoolMaxCapacity(t *testing.T) {
+	p := NewPool(100)
+
+	for i := 0; i < 100; i++ {
+		q := New()
+		for j := 0; j < 10000; j++ {
+			q.Add(j)
+		}
+		p.Put(q)
+	}
+
+	if p.Estimate() != 100 {
+		t.Error("capped pool has estimate", p.Estimate())
+	}
+}
+
+func TestPoolCapsBuffers(t *testing.T) {
+	p := NewPool(20)
+
+	for i := 0; i < 100; i++ {
+		q := p.Get()
+		if len(q.buf) > 20 {
+			t.Fatal("pool returned queue with capacity", len(q.buf))
+		}
+		for j := 0; j < 100; j++ {
+			q.Add(j)
+		}
+		p.Put(q)
+	}
+
+	if q := p.Get(); len(q.buf) != 20 {
+		t.Error("pool returned queue with capacity", len(q.buf))
+	}
+}
@@ -9,14 +9,72 @@ package queue
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
 )
 
 const minQueueLen = 16
 
-// Queue represents a single instance of the queue data structure.
+var (
+	// ErrEmptyQueue is returned when peeking at or removing from an empty queue.
+	ErrEmptyQueue = errors.New("queue: empty queue")
+	// ErrOutOfRange is returned when an index does not refer to an element of the queue.
+	ErrOutOfRange = errors.New("queue: index out of range")
+	// ErrQueueFull is returned when adding to a queue that cannot grow.
+	ErrQueueFull = errors.New("queue: queue is full")
+)
+
+// Interface is the set of queue operations shared by Queue and
+// ThreadSafeQueue, for code that works with either or with a test double.
+type Interface interface {
+	Add(elem interface{})
+	Pop() (interface{}, error)
+	Peek() (interface{}, error)
+	Length() int
+}
+
+var _ Interface = (*Queue)(nil)
+
+// Queue represents a single instance of the queue data structure. nil is a
+// valid element; which slots are live is tracked by head and count alone.
 type Queue struct {
 	buf               []interface{}
 	head, tail, count int
+	hooks             *queueHooks
+	window            int
+	growth            float64
+	shrinkDiv         int
+	codec             Codec
+	fixed             bool
+	mods              int // only counted with hooks, which WithModCheck sets
+}
+
+// queueHooks holds the opt-in behaviour that runs as elements enter and leave
+// a queue. It is nil until one of the options or methods that need it is
+// used, so a plain queue pays a single nil check for all of it in Add and
+// Remove.
+type queueHooks struct {
+	lazyNil         bool
+	reclaim         func(elem interface{})
+	keyFn           func(elem interface{}) interface{}
+	index           map[interface{}]int
+	tee             *Queue
+	sizeOf          func(elem interface{}) int64
+	bytes, maxBytes int64
+	recorder        *Queue
+	shared          *int32
+	modCheck        bool
+	less            func(a, b interface{}) bool
+}
+
+// returns the queue's hooks, allocating them if it has none yet
+func (q *Queue) ensureHooks() *queueHooks {
+	if q.hooks == nil {
+		q.hooks = new(queueHooks)
+	}
+	return q.hooks
 }
 
 // New constructs and returns a new Queue.
@@ -26,79 +84,998 @@ func New() *Queue {
 	}
 }
 
+// constructs a queue with room for at least n elements before resizing
+func newWithCapacity(n int) *Queue {
+	if n < minQueueLen {
+		n = minQueueLen
+	}
+	return &Queue{
+		buf: make([]interface{}, n),
+	}
+}
+
+// NewWindow constructs and returns a new Queue for use as a sliding window
+// of the given size with Slide. The window size does not limit Add.
+func NewWindow(size int) *Queue {
+	q := New()
+	q.window = size
+	return q
+}
+
+// NewSized constructs and returns a new Queue that limits the total size of
+// its elements, as measured by sizeOf, to maxBytes. Whenever an addition takes
+// the total over the limit, elements are removed from the front until it fits
+// again; an element bigger than maxBytes on its own evicts everything,
+// including itself. PrependSlice, which adds at the front, evicts from the
+// end instead, so the prepended elements are kept. With EnableOrdering the
+// front holds the smallest elements, so an element that sorts first into a
+// full queue evicts itself.
+func NewSized(maxBytes int64, sizeOf func(elem interface{}) int64) *Queue {
+	q := New()
+	h := q.ensureHooks()
+	h.maxBytes = maxBytes
+	h.sizeOf = sizeOf
+	return q
+}
+
+// SetReclaimFunc sets a function to be called with each element the queue
+// discards, e.g. to return it to a sync.Pool: those dropped by Remove,
+// RemoveAll, RemoveAllComparable, ReplaceAll, a RemoveIterator or a sized
+// queue's eviction, those passed to PopAll's callback, once it returns, and
+// those written by DrainWriter, once they are flushed. Elements handed back to the caller by Pop,
+// PopOK, PopRandom, Slide, DrainWhere or ThreadSafeQueue.PopBatch, or moved
+// by MoveN, are not reclaimed. Pass nil to stop
+// reclaiming elements.
+func (q *Queue) SetReclaimFunc(fn func(elem interface{})) {
+	q.ensureHooks().reclaim = fn
+}
+
+// TeeTo makes every element subsequently added to the queue, by any method,
+// also be added to the end of other. Elements already in the queue are not
+// copied. Only one tee is active at a time, and queues must not tee into
+// each other in a cycle.
+func (q *Queue) TeeTo(other *Queue) {
+	q.ensureHooks().tee = other
+}
+
+// StopTee stops copying added elements to the queue set by TeeTo.
+func (q *Queue) StopTee() {
+	if q.hooks != nil {
+		q.hooks.tee = nil
+	}
+}
+
 // Length returns the number of elements currently stored in the queue.
 func (q *Queue) Length() int {
 	return q.count
 }
 
-// resizes the queue to fit exactly twice its current contents
-// this can result in shrinking if the queue is less than half-full
+// Bytes returns the total size of the elements in a queue created with
+// NewSized, and 0 for any other queue.
+func (q *Queue) Bytes() int64 {
+	if q.hooks == nil {
+		return 0
+	}
+	return q.hooks.bytes
+}
+
+// IsEmpty reports whether the queue holds no elements.
+func (q *Queue) IsEmpty() bool {
+	return q.count == 0
+}
+
+// IsFull reports whether a queue created with NewWindow holds at least its
+// window size, so that the next Slide will evict. Other queues grow as needed
+// and are never full.
+func (q *Queue) IsFull() bool {
+	return q.window > 0 && q.count >= q.window
+}
+
+// IsWrapped reports whether the elements of the queue straddle the end of
+// the underlying buffer, i.e. whether head+Length() exceeds its capacity. A
+// full queue with its head at the start of the buffer has tail == head but is
+// contiguous, so it is not considered wrapped; an empty queue never is.
+func (q *Queue) IsWrapped() bool {
+	return q.head+q.count > len(q.buf)
+}
+
+// resizes the queue to fit exactly its current contents scaled by the growth
+// factor (twice, by default), but never below minQueueLen
+// this can result in shrinking if the queue is sparsely filled
 func (q *Queue) resize() {
-	newBuf := make([]interface{}, q.count*2)
+	size := q.scale(q.count)
+	if size < minQueueLen {
+		size = minQueueLen
+	}
+	q.resizeTo(size)
+}
 
-	if q.tail > q.head {
-		copy(newBuf, q.buf[q.head:q.tail])
-	} else {
-		n := copy(newBuf, q.buf[q.head:])
-		copy(newBuf[n:], q.buf[:q.tail])
+// returns n scaled up by the growth factor, always at least n+1
+func (q *Queue) scale(n int) int {
+	if q.growth == 0 {
+		return n * 2
 	}
 
+	size := int(math.Ceil(float64(n) * q.growth))
+	if size <= n {
+		size = n + 1
+	}
+	return size
+}
+
+// reallocates the buffer to the given size, which must fit the contents
+func (q *Queue) resizeTo(size int) {
+	newBuf := make([]interface{}, size)
+	q.copyInto(newBuf)
+
+	q.dropShare()
 	q.head = 0
 	q.tail = q.count
 	q.buf = newBuf
 }
 
+// makes room for n more elements with at most one resize, scaling the
+// buffer by the growth factor as many times as needed
+func (q *Queue) grow(n int) {
+	size := len(q.buf)
+	for size < q.count+n {
+		size = q.scale(size)
+	}
+	if size != len(q.buf) {
+		q.resizeTo(size)
+	}
+}
+
+// copies up to len(dst) elements from the head of the queue into dst,
+// handling wraparound, and returns the number of elements copied
+func (q *Queue) copyInto(dst []interface{}) int {
+	n := len(dst)
+	if n > q.count {
+		n = q.count
+	}
+
+	if q.head+n <= len(q.buf) {
+		copy(dst, q.buf[q.head:q.head+n])
+	} else {
+		k := copy(dst, q.buf[q.head:])
+		copy(dst[k:n], q.buf[:n-k])
+	}
+
+	return n
+}
+
 // Add puts an element on the end of the queue.
 func (q *Queue) Add(elem interface{}) {
+	if q.hooks != nil {
+		if q.hooks.recorder != nil {
+			q.record("Add", elem)
+		}
+		q.addHooked(elem)
+		return
+	}
+
+	// the same as add, repeated to save a call on the hot path
+	if q.count == len(q.buf) {
+		q.resize()
+	}
+
+	q.buf[q.tail] = elem
+	q.tail = (q.tail + 1) % len(q.buf)
+	q.count++
+}
+
+// smallInts holds preboxed ints in [0, smallIntsLen), so AddInt can store
+// them without allocating a new interface value on each call.
+const smallIntsLen = 256
+
+var smallInts [smallIntsLen]interface{}
+
+func init() {
+	for i := range smallInts {
+		smallInts[i] = i
+	}
+}
+
+// AddInt puts an int onto the end of the queue. Ints from 0 to 255 reuse a
+// shared boxed value instead of being boxed on every add; any other int is
+// added exactly as Add would add it. This only saves allocations on Go
+// releases before 1.15: since then, the runtime boxes integers from 0 to 255
+// without allocating, so Add is already as cheap for them.
+func (q *Queue) AddInt(i int) {
+	if i >= 0 && i < smallIntsLen {
+		q.Add(smallInts[i])
+		return
+	}
+	q.Add(i)
+}
+
+func (q *Queue) add(elem interface{}) {
+	if q.hooks != nil {
+		q.addHooked(elem)
+		return
+	}
+
+	if q.count == len(q.buf) {
+		q.resize()
+	}
+
+	q.buf[q.tail] = elem
+	q.tail = (q.tail + 1) % len(q.buf)
+	q.count++
+}
+
+// add, for a queue with hooks
+func (q *Queue) addHooked(elem interface{}) {
+	if q.hooks.less != nil {
+		q.insert(q.search(elem), elem)
+		return
+	}
+
 	if q.count == len(q.buf) {
 		q.resize()
 	}
 
+	q.unshare()
+	q.admit(elem)
 	q.buf[q.tail] = elem
 	q.tail = (q.tail + 1) % len(q.buf)
 	q.count++
+	q.evict()
+}
+
+// Enqueue is an alias for Add.
+func (q *Queue) Enqueue(elem interface{}) {
+	q.Add(elem)
+}
+
+// AddUnique puts an element on the end of the queue unless eq reports that
+// it equals the element currently at the end, and returns whether the element
+// was added. Only the last element is compared, so this suppresses runs of
+// consecutive duplicates in O(1).
+func (q *Queue) AddUnique(elem interface{}, eq func(a, b interface{}) bool) bool {
+	if q.count > 0 && eq(elem, q.buf[(q.tail-1+len(q.buf))%len(q.buf)]) {
+		return false
+	}
+
+	q.Add(elem)
+	return true
+}
+
+// EnableOrdering sorts the queue by less, keeping the existing order of
+// equal elements, and makes every subsequent Add insert its element after
+// all elements not greater than it, so the queue stays sorted. Sorting costs
+// O(n log n) and each ordered Add O(n). PrependSlice, ReplaceAll and the
+// other methods that place elements explicitly ignore the ordering.
+func (q *Queue) EnableOrdering(less func(a, b interface{}) bool) {
+	if q.recording() {
+		q.record("EnableOrdering")
+	}
+
+	q.unshare()
+	q.mods++
+	q.ensureHooks().less = less
+	sort.Stable(sorter{q})
+}
+
+// DisableOrdering makes Add append to the end of the queue again.
+func (q *Queue) DisableOrdering() {
+	if q.recording() {
+		q.record("DisableOrdering")
+	}
+	if q.hooks != nil {
+		q.hooks.less = nil
+	}
+}
+
+// sorts the live elements of a queue by its less function
+type sorter struct {
+	q *Queue
+}
+
+func (s sorter) Len() int {
+	return s.q.count
+}
+
+func (s sorter) Less(i, j int) bool {
+	q := s.q
+	return q.hooks.less(q.buf[(q.head+i)%len(q.buf)], q.buf[(q.head+j)%len(q.buf)])
+}
+
+func (s sorter) Swap(i, j int) {
+	q := s.q
+	i, j = (q.head+i)%len(q.buf), (q.head+j)%len(q.buf)
+	q.buf[i], q.buf[j] = q.buf[j], q.buf[i]
+}
+
+// returns the index after every element not greater than elem
+func (q *Queue) search(elem interface{}) int {
+	return sort.Search(q.count, func(i int) bool {
+		return q.hooks.less(elem, q.buf[(q.head+i)%len(q.buf)])
+	})
+}
+
+// inserts elem so that it ends up at index i, shifting whichever side of
+// the queue is shorter to make room
+func (q *Queue) insert(i int, elem interface{}) {
+	q.admit(elem)
+	q.grow(1)
+	q.unshare()
+
+	n := len(q.buf)
+	if i < q.count/2 {
+		q.head = (q.head - 1 + n) % n
+		for j := 0; j < i; j++ {
+			q.buf[(q.head+j)%n] = q.buf[(q.head+j+1)%n]
+		}
+	} else {
+		for j := q.count; j > i; j-- {
+			q.buf[(q.head+j)%n] = q.buf[(q.head+j-1)%n]
+		}
+		q.tail = (q.tail + 1) % n
+	}
+
+	q.buf[(q.head+i)%n] = elem
+	q.count++
+	q.evict()
+}
+
+// PrependSlice puts all of elems on the front of the queue, keeping their
+// order, so that elems[0] becomes the new head. On a queue created with
+// NewSized, elements are evicted from the end to make room for them.
+func (q *Queue) PrependSlice(elems []interface{}) {
+	if q.recording() {
+		q.record("PrependSlice", elems)
+	}
+	q.grow(len(elems))
+	q.unshare()
+
+	q.head = (q.head - len(elems)%len(q.buf) + len(q.buf)) % len(q.buf)
+	for i, elem := range elems {
+		q.admit(elem)
+		q.buf[(q.head+i)%len(q.buf)] = elem
+	}
+	q.count += len(elems)
+	q.evictTail()
+}
+
+// ReplaceAll replaces the contents of the queue with elems, in order. The
+// existing buffer is reused if it is large enough.
+func (q *Queue) ReplaceAll(elems []interface{}) {
+	if q.recording() {
+		q.record("ReplaceAll", elems)
+	}
+	for i := 0; i < q.count; i++ {
+		elem := q.buf[(q.head+i)%len(q.buf)]
+		q.release(elem)
+		q.discard(elem)
+	}
+
+	size := len(q.buf)
+	for size < len(elems) {
+		size = q.scale(size)
+	}
+
+	if size == len(q.buf) {
+		q.unshare()
+		n := copy(q.buf, elems)
+		for i := n; i < len(q.buf); i++ {
+			q.buf[i] = nil
+		}
+	} else {
+		q.dropShare()
+		q.buf = make([]interface{}, size)
+		copy(q.buf, elems)
+	}
+
+	for _, elem := range elems {
+		q.admit(elem)
+	}
+
+	q.head = 0
+	q.count = len(elems)
+	q.tail = q.count % len(q.buf)
+	q.evict()
+}
+
+// RotateNormalized rotates the queue by n places, so that the element at
+// index n becomes the head (negative n rotates the other way), and leaves the
+// contents unwrapped at the start of the buffer. This always copies the whole
+// queue, so it costs O(n) in the length of the queue.
+func (q *Queue) RotateNormalized(n int) {
+	if q.recording() {
+		q.record("RotateNormalized", n)
+	}
+	k := 0
+	if q.count > 0 {
+		k = (n%q.count + q.count) % q.count
+	}
+	q.mods++
+
+	newBuf := make([]interface{}, len(q.buf))
+	for i := 0; i < q.count; i++ {
+		newBuf[i] = q.buf[(q.head+(i+k)%q.count)%len(q.buf)]
+	}
+
+	q.dropShare()
+	q.head = 0
+	q.tail = q.count % len(newBuf)
+	q.buf = newBuf
 }
 
-// Peek returns the element at the head of the queue. This call panics
-// if the queue is empty.
+// Slide puts an element on the end of the queue. If the queue was created
+// with NewWindow and already holds at least its window size, the element at
+// the head is removed first and returned, with ok set to true.
+func (q *Queue) Slide(elem interface{}) (evicted interface{}, ok bool) {
+	if q.recording() {
+		q.record("Slide", elem)
+	}
+
+	if q.IsFull() {
+		evicted, ok = q.pop(), true
+	}
+
+	q.add(elem)
+	return evicted, ok
+}
+
+// Peek returns the element at the head of the queue. This call returns
+// ErrEmptyQueue if the queue is empty.
 func (q *Queue) Peek() (interface{}, error) {
 	if q.count <= 0 {
-		return nil, errors.New("queue: Peek() called on empty queue")
+		return nil, ErrEmptyQueue
 	}
 	return q.buf[q.head], nil
 }
 
+// PeekOK returns the element at the head of the queue. The second return
+// value is false if the queue is empty.
+func (q *Queue) PeekOK() (interface{}, bool) {
+	if q.count <= 0 {
+		return nil, false
+	}
+	return q.buf[q.head], true
+}
+
 // Get returns the element at index i in the queue. If the index is
-// invalid, the call will panic.
+// invalid, the call returns ErrOutOfRange.
 func (q *Queue) Get(i int) (interface{}, error) {
 	if i < 0 || i >= q.count {
-		return nil, errors.New("queue: Get() called with index out of range")
+		return nil, ErrOutOfRange
 	}
 	return q.buf[(q.head+i)%len(q.buf)], nil
 }
 
+// PeekInto copies up to len(dst) elements from the head of the queue into
+// dst, in order, without removing them, and returns the number copied. It
+// never allocates.
+func (q *Queue) PeekInto(dst []interface{}) int {
+	return q.copyInto(dst)
+}
+
+// ToSlice returns a new slice holding the elements of the queue, in order.
+func (q *Queue) ToSlice() []interface{} {
+	elems := make([]interface{}, q.count)
+	q.copyInto(elems)
+	return elems
+}
+
+// ContiguousSlice returns the elements of the queue, in order, as a slice of
+// the underlying buffer, without copying. It returns nil and false if the
+// queue is empty or wrapped; see IsWrapped and RotateNormalized.
+//
+// The returned slice aliases the queue's internal storage: it must not be
+// modified, and it is invalidated by any subsequent change to the queue.
+func (q *Queue) ContiguousSlice() ([]interface{}, bool) {
+	if q.count == 0 || q.IsWrapped() {
+		return nil, false
+	}
+	return q.buf[q.head : q.head+q.count], true
+}
+
+// ForEachLimit calls fn on up to n elements from the head of the queue, in
+// order, without removing them, and returns the number of elements visited.
+func (q *Queue) ForEachLimit(n int, fn func(elem interface{})) int {
+	if n > q.count {
+		n = q.count
+	} else if n < 0 {
+		n = 0
+	}
+
+	mods := q.mods
+	for i := 0; i < n; i++ {
+		fn(q.buf[(q.head+i)%len(q.buf)])
+		q.checkMods(mods)
+	}
+	return n
+}
+
+// LastIndexOf returns the index of the last element in the queue for which
+// pred returns true, or -1 if there is none. Elements are tested starting
+// from the end of the queue.
+func (q *Queue) LastIndexOf(pred func(elem interface{}) bool) int {
+	for i := q.count - 1; i >= 0; i-- {
+		if pred(q.buf[(q.head+i)%len(q.buf)]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindLast returns the last element in the queue for which pred returns
+// true. The second return value is false if there is no such element.
+func (q *Queue) FindLast(pred func(elem interface{}) bool) (interface{}, bool) {
+	i := q.LastIndexOf(pred)
+	if i < 0 {
+		return nil, false
+	}
+	return q.buf[(q.head+i)%len(q.buf)], true
+}
+
+// IndexByKey returns a new map from the key keyFn gives each element to the
+// element itself. Where several elements share a key, the one nearest the
+// end of the queue wins. The map is a snapshot and is not updated when the
+// queue changes; see WithIndex for a maintained index.
+func (q *Queue) IndexByKey(keyFn func(elem interface{}) interface{}) map[interface{}]interface{} {
+	index := make(map[interface{}]interface{}, q.count)
+	for i := 0; i < q.count; i++ {
+		elem := q.buf[(q.head+i)%len(q.buf)]
+		index[keyFn(elem)] = elem
+	}
+	return index
+}
+
+// Partition returns two new queues holding, in order, the elements for
+// which pred returns true and those for which it returns false. The queue
+// itself is not modified. Both results start with room for every element of
+// the queue, so partitioning never resizes them; call Shrink on a result to
+// trim its buffer.
+func (q *Queue) Partition(pred func(elem interface{}) bool) (matched, unmatched *Queue) {
+	matched, unmatched = newWithCapacity(q.count), newWithCapacity(q.count)
+	for i := 0; i < q.count; i++ {
+		elem := q.buf[(q.head+i)%len(q.buf)]
+		if pred(elem) {
+			matched.Add(elem)
+		} else {
+			unmatched.Add(elem)
+		}
+	}
+	return matched, unmatched
+}
+
+// Compare compares the queue with other lexicographically, using cmp to
+// compare elements in order. It returns -1, 0 or +1 as the queue is less
+// than, equal to or greater than other; if one queue is a prefix of the
+// other, the shorter one is less.
+func (q *Queue) Compare(other *Queue, cmp func(a, b interface{}) int) int {
+	for i := 0; i < q.count && i < other.count; i++ {
+		c := cmp(q.buf[(q.head+i)%len(q.buf)], other.buf[(other.head+i)%len(other.buf)])
+		if c < 0 {
+			return -1
+		} else if c > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case q.count < other.count:
+		return -1
+	case q.count > other.count:
+		return 1
+	}
+	return 0
+}
+
 // Gets and returns the first item from the queue.
 func (q *Queue) Pop() (interface{}, error) {
-	item, err := q.Peek()
-	if err != nil {
-		return nil, err
+	if q.count <= 0 {
+		return nil, ErrEmptyQueue
+	}
+	if q.recording() {
+		q.record("Pop")
+	}
+
+	return q.pop(), nil
+}
+
+// removes and returns the head of a non-empty queue
+func (q *Queue) pop() interface{} {
+	item := q.removeHead()
+	q.maybeShrink()
+	return item
+}
+
+// PopOK removes and returns the element at the head of the queue. The
+// second return value is false if the queue is empty.
+func (q *Queue) PopOK() (interface{}, bool) {
+	elem, err := q.Pop()
+	return elem, err == nil
+}
+
+// Dequeue is an alias for Pop.
+func (q *Queue) Dequeue() (interface{}, error) {
+	return q.Pop()
+}
+
+// PopAll removes every element from the queue in order, calling fn on each
+// as it is removed, until the queue is empty. Elements that fn adds are
+// popped too. Each element is passed to the reclaim func, if any, only after
+// fn returns. The queue only checks whether to shrink once, at the end.
+func (q *Queue) PopAll(fn func(elem interface{})) {
+	if q.recording() {
+		q.record("PopAll")
 	}
 
-	return item, q.Remove()
+	for q.count > 0 {
+		elem := q.removeHead()
+		fn(elem)
+		q.discard(elem)
+	}
+	q.maybeShrink()
+}
+
+// PopRandom removes and returns an element chosen uniformly at random using
+// rng. The chosen element is swapped with the head before removing it, so
+// this is O(1) but does not preserve the order of the remaining elements.
+// This call returns ErrEmptyQueue if the queue is empty.
+func (q *Queue) PopRandom(rng *rand.Rand) (interface{}, error) {
+	if q.count <= 0 {
+		return nil, ErrEmptyQueue
+	}
+
+	i := rng.Intn(q.count)
+	if q.recording() {
+		q.record("PopRandom", i)
+	}
+
+	q.unshare()
+	q.mods++
+	i = (q.head + i) % len(q.buf)
+	q.buf[q.head], q.buf[i] = q.buf[i], q.buf[q.head]
+	return q.pop(), nil
+}
+
+// PopWithLen removes and returns the first item from the queue, along with
+// the number of elements remaining after the removal.
+func (q *Queue) PopWithLen() (elem interface{}, remaining int, err error) {
+	elem, err = q.Pop()
+	return elem, q.count, err
 }
 
 // Remove removes the element from the front of the queue. If you actually
-// want the element, call Peek first. This call panics if the queue is empty.
+// want the element, call Peek first. This call returns ErrEmptyQueue if the
+// queue is empty.
 func (q *Queue) Remove() error {
 	if q.count <= 0 {
-		return errors.New("queue: Remove() called on empty queue")
+		return ErrEmptyQueue
+	}
+	if q.recording() {
+		q.record("Remove")
+	}
+
+	q.remove()
+	return nil
+}
+
+// removes the head of a non-empty queue
+func (q *Queue) remove() {
+	// plain queues skip removeHead to save a call on the hot path
+	if q.hooks == nil {
+		q.buf[q.head] = nil
+		q.head = (q.head + 1) % len(q.buf)
+		q.count--
+	} else {
+		q.discard(q.removeHead())
+	}
+	q.maybeShrink()
+}
+
+// removes the head of a non-empty queue without shrinking or reclaiming it,
+// returning it
+func (q *Queue) removeHead() interface{} {
+	elem := q.buf[q.head]
+	if q.hooks == nil {
+		q.buf[q.head] = nil
+	} else {
+		q.release(elem)
+		if !q.hooks.lazyNil {
+			q.unshare()
+			q.buf[q.head] = nil
+		}
 	}
-	q.buf[q.head] = nil
 	q.head = (q.head + 1) % len(q.buf)
 	q.count--
-	if len(q.buf) > minQueueLen && q.count*4 == len(q.buf) {
+	return elem
+}
+
+// removes and returns the last element of a non-empty queue
+func (q *Queue) removeTail() interface{} {
+	q.unshare()
+	q.tail = (q.tail - 1 + len(q.buf)) % len(q.buf)
+	elem := q.buf[q.tail]
+	q.release(elem)
+	q.discard(elem)
+	q.buf[q.tail] = nil
+	q.count--
+	q.maybeShrink()
+	return elem
+}
+
+// removes the element at index i, shifting whichever side of the queue is
+// shorter to close the gap
+func (q *Queue) removeAt(i int) {
+	n := len(q.buf)
+	elem := q.buf[(q.head+i)%n]
+	q.release(elem)
+	q.discard(elem)
+	q.unshare()
+
+	if i < q.count/2 {
+		for j := i; j > 0; j-- {
+			q.buf[(q.head+j)%n] = q.buf[(q.head+j-1)%n]
+		}
+		q.buf[q.head] = nil
+		q.head = (q.head + 1) % n
+	} else {
+		for j := i; j < q.count-1; j++ {
+			q.buf[(q.head+j)%n] = q.buf[(q.head+j+1)%n]
+		}
+		q.tail = (q.tail - 1 + n) % n
+		q.buf[q.tail] = nil
+	}
+
+	q.count--
+	q.maybeShrink()
+}
+
+// Shrink reallocates the buffer to fit the current contents, scaled by the
+// growth factor, if the queue is less than half full. Removing elements one
+// at a time shrinks the queue automatically; Shrink reclaims memory after
+// bulk removals, or sooner than the automatic policy would. It does nothing
+// for an ArrayQueue.
+func (q *Queue) Shrink() {
+	if !q.fixed && len(q.buf) > minQueueLen && q.count*2 < len(q.buf) && q.scale(q.count) < len(q.buf) {
 		q.resize()
 	}
+}
+
+// shrinks the buffer if the automatic shrink policy calls for it; small
+// enough to inline, so a queue at its minimum size doesn't make a call
+func (q *Queue) maybeShrink() {
+	if len(q.buf) > minQueueLen {
+		q.shrinkIfSparse()
+	}
+}
+
+func (q *Queue) shrinkIfSparse() {
+	if !q.fixed && q.shouldShrink() {
+		q.resize()
+	}
+}
+
+// reports whether the contents have dropped far enough below the capacity
+// for resize to shrink the buffer
+func (q *Queue) shouldShrink() bool {
+	if q.shrinkDiv == 0 {
+		return q.scale(q.scale(q.count)) <= len(q.buf)
+	}
+	// a divisor no larger than the square of the growth factor would shrink
+	// to a buffer that is already almost full, so the default policy bounds it
+	return q.count*q.shrinkDiv <= len(q.buf) && q.scale(q.scale(q.count)) <= len(q.buf)
+}
+
+// DrainWhere removes the elements for which pred returns true and returns
+// them in order. The remaining elements keep their order and are compacted in
+// a single pass, so this is O(n) no matter how many elements match.
+func (q *Queue) DrainWhere(pred func(elem interface{}) bool) []interface{} {
+	if q.recording() {
+		q.record("DrainWhere")
+	}
+
+	var drained []interface{}
+	q.removeWhere(pred, func(elem interface{}) {
+		drained = append(drained, elem)
+	})
+	return drained
+}
+
+// RemoveAll removes every element for which eq(elem, value) returns true,
+// keeping the order of the rest, and returns how many were removed. Like
+// DrainWhere, it compacts the queue in a single pass.
+func (q *Queue) RemoveAll(value interface{}, eq func(a, b interface{}) bool) int {
+	if q.recording() {
+		q.record("RemoveAll", value)
+	}
+
+	return q.removeWhere(func(elem interface{}) bool {
+		return eq(elem, value)
+	}, nil)
+}
+
+// RemoveAllComparable removes every element equal to value, compared with ==,
+// and returns how many were removed. It panics if an element and value have
+// the same uncomparable type, such as a slice.
+func (q *Queue) RemoveAllComparable(value interface{}) int {
+	if q.recording() {
+		q.record("RemoveAllComparable", value)
+	}
+
+	return q.removeWhere(func(elem interface{}) bool {
+		return elem == value
+	}, nil)
+}
+
+// removes the elements matching pred in a single pass, passing each to
+// removed if it is not nil and otherwise discarding it, and returns how many
+// were removed
+func (q *Queue) removeWhere(pred func(elem interface{}) bool, removed func(elem interface{})) int {
+	q.unshare()
+
+	n := len(q.buf)
+	kept := 0
+	for i := 0; i < q.count; i++ {
+		elem := q.buf[(q.head+i)%n]
+		if pred(elem) {
+			q.release(elem)
+			if removed != nil {
+				removed(elem)
+			} else {
+				q.discard(elem)
+			}
+		} else {
+			q.buf[(q.head+kept)%n] = elem
+			kept++
+		}
+	}
+
+	for i := kept; i < q.count; i++ {
+		q.buf[(q.head+i)%n] = nil
+	}
+	dropped := q.count - kept
+	q.count = kept
+	q.tail = (q.head + kept) % n
+	q.maybeShrink()
+
+	return dropped
+}
+
+// SweepDead clears every slot of the buffer outside the live elements,
+// releasing references left behind by Remove in WithLazyNil mode.
+func (q *Queue) SweepDead() {
+	q.unshare()
+
+	n := len(q.buf)
+	for i := q.count; i < n; i++ {
+		q.buf[(q.head+i)%n] = nil
+	}
+}
+
+// ContainsKey reports whether the queue holds an element with the given key.
+// It requires the queue to be constructed WithIndex, and otherwise always
+// returns false.
+func (q *Queue) ContainsKey(key interface{}) bool {
+	return q.CountKey(key) > 0
+}
+
+// CountKey returns the number of elements in the queue with the given key.
+// It requires the queue to be constructed WithIndex, and otherwise always
+// returns 0.
+func (q *Queue) CountKey(key interface{}) int {
+	if q.hooks == nil {
+		return 0
+	}
+	return q.hooks.index[key]
+}
+
+// called with each element entering the queue
+func (q *Queue) admit(elem interface{}) {
+	h := q.hooks
+	if h == nil {
+		return
+	}
+
+	q.mods++
+	if h.keyFn != nil {
+		h.index[h.keyFn(elem)]++
+	}
+	if h.tee != nil {
+		h.tee.Add(elem)
+	}
+	if h.sizeOf != nil {
+		h.bytes += h.sizeOf(elem)
+	}
+}
+
+// called with each element leaving the queue
+func (q *Queue) release(elem interface{}) {
+	h := q.hooks
+	if h == nil {
+		return
+	}
+
+	q.mods++
+	if h.keyFn != nil {
+		key := h.keyFn(elem)
+		if h.index[key] <= 1 {
+			delete(h.index, key)
+		} else {
+			h.index[key]--
+		}
+	}
+	if h.sizeOf != nil {
+		h.bytes -= h.sizeOf(elem)
+	}
+}
+
+// called with each released element that is being thrown away, rather than
+// moved elsewhere
+func (q *Queue) discard(elem interface{}) {
+	if q.hooks != nil && q.hooks.reclaim != nil {
+		q.hooks.reclaim(elem)
+	}
+}
+
+// panics if the queue has been modified since mods was read from it, when
+// constructed WithModCheck
+func (q *Queue) checkMods(mods int) {
+	if q.hooks != nil && q.hooks.modCheck && q.mods != mods {
+		panic("queue: queue modified during iteration")
+	}
+}
+
+// removes elements from the front until a sized queue is within its limit
+func (q *Queue) evict() {
+	h := q.hooks
+	if h == nil || h.sizeOf == nil {
+		return
+	}
+	for h.bytes > h.maxBytes && q.count > 0 {
+		q.remove()
+	}
+}
+
+// removes elements from the end until a sized queue is within its limit, for
+// additions made at the front
+func (q *Queue) evictTail() {
+	h := q.hooks
+	if h == nil || h.sizeOf == nil {
+		return
+	}
+	for h.bytes > h.maxBytes && q.count > 0 {
+		q.removeTail()
+	}
+}
+
+// Validate checks the internal consistency of the queue, returning a
+// descriptive error if any invariant is violated. It is intended as a
+// debugging aid for tests and should never fail in correct code.
+func (q *Queue) Validate() error {
+	return q.checkInvariants()
+}
+
+func (q *Queue) checkInvariants() error {
+	n := len(q.buf)
+	if n == 0 {
+		return errors.New("queue: buffer has zero length")
+	}
+	if q.count < 0 || q.count > n {
+		return fmt.Errorf("queue: count %d out of range [0, %d]", q.count, n)
+	}
+	if q.head < 0 || q.head >= n {
+		return fmt.Errorf("queue: head %d out of range [0, %d)", q.head, n)
+	}
+	if q.tail < 0 || q.tail >= n {
+		return fmt.Errorf("queue: tail %d out of range [0, %d)", q.tail, n)
+	}
+	if (q.head+q.count)%n != q.tail {
+		return fmt.Errorf("queue: head %d and tail %d don't span count %d", q.head, q.tail, q.count)
+	}
+
+	// live elements may themselves be nil, so only dead slots are checked
+	for i := q.count; i < n && (q.hooks == nil || !q.hooks.lazyNil); i++ {
+		if q.buf[(q.head+i)%n] != nil {
+			return fmt.Errorf("queue: slot %d outside live region is not nil", (q.head+i)%n)
+		}
+	}
 
 	return nil
 }
@@ -1,6 +1,11 @@
 package queue
 
-import "testing"
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+)
 
 func TestQueueSimple(t *testing.T) {
 	q := New()
@@ -69,6 +74,251 @@ func TestQueueGet(t *testing.T) {
 	}
 }
 
+func TestQueuePeekInto(t *testing.T) {
+	q := New()
+	dst := make([]interface{}, 10)
+
+	if n := q.PeekInto(dst); n != 0 {
+		t.Error("peeked", n, "elements from empty queue")
+	}
+
+	for i := 0; i < minQueueLen; i++ {
+		q.Add(i)
+	}
+	for i := 0; i < 12; i++ {
+		q.Remove()
+		q.Add(minQueueLen + i)
+	}
+
+	// the head is near the end of the buffer, so the copy wraps
+	if n := q.PeekInto(dst); n != 10 {
+		t.Fatal("peeked", n, "elements")
+	}
+	for i, e := range dst {
+		if e.(int) != i+12 {
+			t.Errorf("index %d doesn't contain %d", i, i+12)
+		}
+	}
+
+	big := make([]interface{}, 100)
+	if n := q.PeekInto(big); n != minQueueLen || q.Length() != minQueueLen {
+		t.Error("peeked", n, "elements into large slice")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		q.PeekInto(dst)
+	})
+	if allocs != 0 {
+		t.Error("PeekInto allocated", allocs, "times")
+	}
+}
+
+func TestQueueAddInt(t *testing.T) {
+	q := New()
+
+	for i := -1; i <= smallIntsLen; i++ {
+		q.AddInt(i)
+	}
+	for i := -1; i <= smallIntsLen; i++ {
+		if elem, _ := q.Pop(); elem != i {
+			t.Errorf("popped %v, expected %d", elem, i)
+		}
+	}
+
+	// the buffer has grown already, so only boxing could allocate; on Go 1.15
+	// and later, Add(5) doesn't allocate either
+	allocs := testing.AllocsPerRun(100, func() {
+		q.AddInt(5)
+		q.Pop()
+	})
+	if allocs != 0 {
+		t.Error("AddInt allocated", allocs, "times")
+	}
+}
+
+func TestQueueToSlice(t *testing.T) {
+	q := New()
+
+	if elems := q.ToSlice(); len(elems) != 0 {
+		t.Error("empty queue gave slice of length", len(elems))
+	}
+
+	for i := 0; i < minQueueLen; i++ {
+		q.Add(i)
+	}
+	for i := 0; i < 3; i++ {
+		q.Remove()
+		q.Add(minQueueLen + i)
+	}
+
+	elems := q.ToSlice()
+	if len(elems) != minQueueLen {
+		t.Fatal("slice has length", len(elems))
+	}
+	for i, e := range elems {
+		if e.(int) != i+3 {
+			t.Errorf("index %d doesn't contain %d", i, i+3)
+		}
+	}
+}
+
+func TestQueueContiguousSlice(t *testing.T) {
+	q := New()
+
+	if _, ok := q.ContiguousSlice(); ok {
+		t.Error("empty queue should have no contiguous slice")
+	}
+
+	for i := 0; i < minQueueLen; i++ {
+		q.Add(i)
+	}
+	q.Remove()
+
+	elems, ok := q.ContiguousSlice()
+	if !ok || len(elems) != minQueueLen-1 || &elems[0] != &q.buf[1] {
+		t.Fatal("unwrapped queue should give a view of its buffer")
+	}
+	for i, e := range elems {
+		if e.(int) != i+1 {
+			t.Errorf("index %d doesn't contain %d", i, i+1)
+		}
+	}
+
+	q.Add(minQueueLen)
+	if _, ok := q.ContiguousSlice(); ok {
+		t.Error("wrapped queue should have no contiguous slice")
+	}
+
+	q.RotateNormalized(0)
+	if elems, ok := q.ContiguousSlice(); !ok || len(elems) != minQueueLen {
+		t.Error("normalized queue should have a contiguous slice")
+	}
+}
+
+func TestQueueForEachLimit(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	var seen []int
+	visit := func(elem interface{}) { seen = append(seen, elem.(int)) }
+
+	if n := q.ForEachLimit(10, visit); n != 10 || len(seen) != 10 {
+		t.Error("visited", n, "of 10 elements")
+	}
+	for i, e := range seen {
+		if e != i {
+			t.Errorf("visit %d had value %d", i, e)
+		}
+	}
+
+	seen = nil
+	if n := q.ForEachLimit(1000, visit); n != 100 || len(seen) != 100 {
+		t.Error("visited", n, "of 100 elements")
+	}
+	if n := q.ForEachLimit(-1, visit); n != 0 {
+		t.Error("visited", n, "elements with negative limit")
+	}
+	if q.Length() != 100 {
+		t.Error("ForEachLimit changed length to", q.Length())
+	}
+}
+
+func TestQueueLastIndexOf(t *testing.T) {
+	q := New()
+
+	even := func(elem interface{}) bool { return elem.(int)%2 == 0 }
+	if q.LastIndexOf(even) != -1 {
+		t.Error("empty queue should have no match")
+	}
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i % 10)
+	}
+
+	if i := q.LastIndexOf(even); i != 998 {
+		t.Error("last even element at", i)
+	}
+	if e, ok := q.FindLast(even); !ok || e.(int) != 8 {
+		t.Error("last even element was", e)
+	}
+	if _, ok := q.FindLast(func(elem interface{}) bool { return elem.(int) > 9 }); ok {
+		t.Error("should not find missing element")
+	}
+}
+
+func TestQueueIndexByKey(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	index := q.IndexByKey(func(elem interface{}) interface{} { return elem.(int) % 10 })
+	if len(index) != 10 {
+		t.Fatal("index has", len(index), "keys")
+	}
+	for key, e := range index {
+		if e.(int) != 90+key.(int) {
+			t.Errorf("key %v maps to %v", key, e)
+		}
+	}
+}
+
+func TestQueuePartition(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+	}
+
+	even, odd := q.Partition(func(elem interface{}) bool { return elem.(int)%2 == 0 })
+	if even.Length() != 500 || odd.Length() != 500 || q.Length() != 1000 {
+		t.Fatal("partitioned lengths were", even.Length(), odd.Length(), q.Length())
+	}
+	if len(even.buf) != 1000 || len(odd.buf) != 1000 {
+		t.Error("partitioned capacities were", len(even.buf), len(odd.buf))
+	}
+	for i := 0; i < 500; i++ {
+		if e, _ := even.Get(i); e.(int) != i*2 {
+			t.Errorf("matched index %d doesn't contain %d", i, i*2)
+		}
+		if e, _ := odd.Get(i); e.(int) != i*2+1 {
+			t.Errorf("unmatched index %d doesn't contain %d", i, i*2+1)
+		}
+	}
+}
+
+func TestQueueCompare(t *testing.T) {
+	cmp := func(a, b interface{}) int { return (a.(int) - b.(int)) * 10 }
+	queue := func(elems ...int) *Queue {
+		q := New()
+		for _, e := range elems {
+			q.Add(e)
+		}
+		return q
+	}
+
+	tests := []struct {
+		a, b *Queue
+		want int
+	}{
+		{queue(), queue(), 0},
+		{queue(1, 2, 3), queue(1, 2, 3), 0},
+		{queue(1, 2), queue(1, 2, 3), -1},
+		{queue(1, 2, 3), queue(1, 2), 1},
+		{queue(1, 3), queue(1, 2, 3), 1},
+		{queue(0, 9, 9), queue(1), -1},
+	}
+	for i, test := range tests {
+		if c := test.a.Compare(test.b, cmp); c != test.want {
+			t.Errorf("comparison %d gave %d, expected %d", i, c, test.want)
+		}
+	}
+}
+
 func TestQueuePops(t *testing.T) {
 	q := New()
 
@@ -83,6 +333,644 @@ func TestQueuePops(t *testing.T) {
 	}
 }
 
+func TestQueuePopRandom(t *testing.T) {
+	q := New()
+	rng := rand.New(rand.NewSource(1))
+
+	if _, err := q.PopRandom(rng); err != ErrEmptyQueue {
+		t.Error("should error when popping empty queue")
+	}
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+	}
+
+	seen := make(map[int]bool)
+	inOrder := true
+	for i := 0; i < 1000; i++ {
+		e, err := q.PopRandom(rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[e.(int)] {
+			t.Error("popped", e, "twice")
+		}
+		seen[e.(int)] = true
+		inOrder = inOrder && e.(int) == i
+	}
+	if inOrder {
+		t.Error("random pops came out in order")
+	}
+	if q.Length() != 0 {
+		t.Error("drained queue has length", q.Length())
+	}
+}
+
+func TestQueuePopAll(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+	}
+
+	popped := 0
+	q.PopAll(func(elem interface{}) {
+		if elem.(int) != popped {
+			t.Errorf("pop %d had value %v", popped, elem)
+		}
+		popped++
+	})
+
+	if popped != 1000 || q.Length() != 0 {
+		t.Error("popped", popped, "elements, leaving", q.Length())
+	}
+}
+
+func TestQueuePopAllReclaim(t *testing.T) {
+	q := New()
+
+	var calls []string
+	q.SetReclaimFunc(func(elem interface{}) {
+		calls = append(calls, "reclaim")
+	})
+	q.Add(0)
+	q.PopAll(func(elem interface{}) {
+		calls = append(calls, "fn")
+	})
+
+	if len(calls) != 2 || calls[0] != "fn" || calls[1] != "reclaim" {
+		t.Error("calls were", calls)
+	}
+}
+
+func TestQueuePopOK(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	for i := 0; i < 100; i++ {
+		buf, head := q.buf, q.head
+		if e, ok := q.PopOK(); !ok || e.(int) != i {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+		if buf[head] != nil {
+			t.Error("popped slot", head, "was not cleared")
+		}
+	}
+	if e, ok := q.PopOK(); ok || e != nil {
+		t.Error("popping empty queue returned", e, ok)
+	}
+}
+
+func TestQueuePopWithLen(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	for i := 0; i < 100; i++ {
+		e, remaining, err := q.PopWithLen()
+		if err != nil || e.(int) != i {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+		if remaining != 100-i-1 {
+			t.Error("popping: queue with", 100-i-1, "elements reported", remaining)
+		}
+	}
+
+	if _, remaining, err := q.PopWithLen(); err == nil || remaining != 0 {
+		t.Error("should error when popping empty queue")
+	}
+}
+
+func TestQueueAddUnique(t *testing.T) {
+	q := New()
+	eq := func(a, b interface{}) bool { return a == b }
+
+	readings := []int{1, 1, 2, 2, 2, 1, 3, 3}
+	for i := 0; i < 10; i++ {
+		for _, r := range readings {
+			q.AddUnique(r, eq)
+		}
+	}
+
+	// the last reading differs from the first, so every pass adds the same run
+	want := []int{1, 2, 1, 3}
+	if q.Length() != 10*len(want) {
+		t.Fatal("queue has length", q.Length())
+	}
+	for i := 0; i < q.Length(); i++ {
+		if e, _ := q.Get(i); e.(int) != want[i%len(want)] {
+			t.Errorf("index %d contains %v", i, e)
+		}
+	}
+
+	if q.AddUnique(3, eq) || !q.AddUnique(4, eq) {
+		t.Error("AddUnique reported the wrong result")
+	}
+}
+
+func TestQueuePrependSlice(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 10; i++ {
+		q.Add(i + 100)
+	}
+
+	batch := make([]interface{}, 100)
+	for i := range batch {
+		batch[i] = i
+	}
+	q.PrependSlice(batch[:10])
+	q.PrependSlice(nil)
+	q.PrependSlice(batch[10:])
+
+	// the second batch is now in front of the first
+	want := append(append(batch[10:], batch[:10]...), 100, 101, 102, 103, 104, 105, 106, 107, 108, 109)
+	if q.Length() != len(want) {
+		t.Fatal("queue has length", q.Length(), "expected", len(want))
+	}
+	for i, w := range want {
+		if e, _ := q.Get(i); e != w {
+			t.Errorf("index %d contains %v, expected %v", i, e, w)
+		}
+	}
+	if err := q.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueueSized(t *testing.T) {
+	q := NewSized(10, func(elem interface{}) int64 { return int64(len(elem.(string))) })
+
+	for _, s := range []string{"abc", "def", "ghi"} {
+		q.Add(s)
+	}
+	if q.Bytes() != 9 || q.Length() != 3 {
+		t.Error("queue has", q.Bytes(), "bytes in", q.Length(), "elements")
+	}
+
+	q.Add("jk")
+	if q.Bytes() != 8 || q.Length() != 3 {
+		t.Error("queue has", q.Bytes(), "bytes in", q.Length(), "elements")
+	}
+	if e, _ := q.Peek(); e.(string) != "def" {
+		t.Error("oldest element was not evicted, head is", e)
+	}
+
+	q.Pop()
+	if q.Bytes() != 5 {
+		t.Error("popped queue has", q.Bytes(), "bytes")
+	}
+
+	q.Add("this is too long")
+	if q.Bytes() != 0 || q.Length() != 0 {
+		t.Error("oversized element left", q.Length(), "elements")
+	}
+
+	if New().Bytes() != 0 {
+		t.Error("unsized queue has bytes")
+	}
+}
+
+func TestQueueSizedPrepend(t *testing.T) {
+	q := NewSized(3, func(elem interface{}) int64 { return 1 })
+
+	for _, s := range []string{"a", "b", "c"} {
+		q.Add(s)
+	}
+	q.PrependSlice([]interface{}{"x", "y"})
+
+	want := []string{"x", "y", "a"}
+	if q.Length() != len(want) || q.Bytes() != 3 {
+		t.Fatal("queue has", q.Bytes(), "bytes in", q.Length(), "elements")
+	}
+	for i, s := range want {
+		if e, _ := q.Get(i); e.(string) != s {
+			t.Errorf("index %d doesn't contain %s", i, s)
+		}
+	}
+
+	// ordered adds still evict from the front, where the smallest are
+	q.EnableOrdering(func(a, b interface{}) bool { return a.(string) < b.(string) })
+	q.Add("0")
+	if e, _ := q.Peek(); q.Length() != 3 || e.(string) != "a" {
+		t.Error("element sorting first into a full queue was kept, head is", e)
+	}
+}
+
+func TestQueueIsEmptyIsFull(t *testing.T) {
+	q := NewWindow(3)
+
+	if !q.IsEmpty() || q.IsFull() {
+		t.Error("new window should be empty")
+	}
+	for i := 0; i < 3; i++ {
+		q.Slide(i)
+	}
+	if q.IsEmpty() || !q.IsFull() {
+		t.Error("window of 3 elements should be full")
+	}
+
+	q = New()
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+		if q.IsEmpty() || q.IsFull() {
+			t.Fatal("unbounded queue with", i+1, "elements is empty or full")
+		}
+	}
+}
+
+func TestQueueIsWrapped(t *testing.T) {
+	q := New()
+
+	if q.IsWrapped() {
+		t.Error("empty queue should not be wrapped")
+	}
+
+	for i := 0; i < minQueueLen; i++ {
+		q.Add(i)
+	}
+	if q.IsWrapped() {
+		t.Error("full queue starting at the buffer head should not be wrapped")
+	}
+
+	q.Remove()
+	q.Add(minQueueLen)
+	if !q.IsWrapped() {
+		t.Error("queue past the end of the buffer should be wrapped")
+	}
+
+	for i := 0; i < minQueueLen-1; i++ {
+		q.Remove()
+	}
+	if q.IsWrapped() {
+		t.Error("queue with only the wrapped element should not be wrapped")
+	}
+}
+
+func TestQueueRotateNormalized(t *testing.T) {
+	q := New()
+
+	for i := 0; i < minQueueLen; i++ {
+		q.Add(i)
+	}
+	for i := 0; i < 3; i++ {
+		q.Remove()
+		q.Add(minQueueLen + i)
+	}
+
+	q.RotateNormalized(2)
+	for i := 0; i < minQueueLen; i++ {
+		if e, _ := q.Get(i); e.(int) != (i+2)%minQueueLen+3 {
+			t.Errorf("index %d contains %v after rotating left", i, e)
+		}
+	}
+	if q.IsWrapped() || q.head != 0 {
+		t.Error("rotated queue should be normalized")
+	}
+
+	q.RotateNormalized(-2 - minQueueLen)
+	for i := 0; i < minQueueLen; i++ {
+		if e, _ := q.Get(i); e.(int) != i+3 {
+			t.Errorf("index %d contains %v after rotating right", i, e)
+		}
+	}
+	if err := q.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	q = New()
+	q.RotateNormalized(5)
+	if q.Length() != 0 {
+		t.Error("rotating empty queue changed its length")
+	}
+}
+
+func TestQueueSlide(t *testing.T) {
+	q := NewWindow(10)
+
+	for i := 0; i < 10; i++ {
+		if _, ok := q.Slide(i); ok {
+			t.Error("slide", i, "evicted before window was full")
+		}
+	}
+	for i := 10; i < 100; i++ {
+		e, ok := q.Slide(i)
+		if !ok || e.(int) != i-10 {
+			t.Error("slide", i, "evicted", e)
+		}
+		if q.Length() != 10 {
+			t.Error("sliding window has length", q.Length())
+		}
+	}
+
+	q = New()
+	for i := 0; i < 100; i++ {
+		if _, ok := q.Slide(i); ok {
+			t.Error("slide without window evicted at", i)
+		}
+	}
+}
+
+func TestQueueReplaceAll(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+		q.Remove()
+		q.Add(i)
+	}
+
+	for _, n := range []int{5, 0, 100} {
+		elems := make([]interface{}, n)
+		for i := range elems {
+			elems[i] = i + n
+		}
+
+		q.ReplaceAll(elems)
+		if q.Length() != n {
+			t.Fatal("replaced queue has length", q.Length(), "expected", n)
+		}
+		for i := 0; i < n; i++ {
+			if e, _ := q.Get(i); e.(int) != i+n {
+				t.Errorf("index %d doesn't contain %d", i, i+n)
+			}
+		}
+		if err := q.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestQueueReclaimFunc(t *testing.T) {
+	q := New()
+
+	var reclaimed []interface{}
+	q.SetReclaimFunc(func(elem interface{}) {
+		reclaimed = append(reclaimed, elem)
+	})
+
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+	}
+	q.Remove()
+	q.Pop()
+	q.DrainWhere(func(elem interface{}) bool { return elem.(int) == 2 })
+	q.RemoveAllComparable(3)
+	q.ReplaceAll([]interface{}{10})
+
+	// popped and drained elements are handed back, so aren't reclaimed
+	expected := []int{0, 3, 4, 5, 6, 7, 8, 9}
+	if len(reclaimed) != len(expected) {
+		t.Fatal("reclaimed", len(reclaimed), "elements")
+	}
+	for i, e := range reclaimed {
+		if e.(int) != expected[i] {
+			t.Errorf("reclaimed element %d was %v, expected %d", i, e, expected[i])
+		}
+	}
+
+	q.SetReclaimFunc(nil)
+	q.Remove()
+	if len(reclaimed) != len(expected) {
+		t.Error("reclaimed after func was unset")
+	}
+}
+
+func TestThreadSafePopBatchReclaim(t *testing.T) {
+	q := NewThreadSafe()
+
+	reclaimed := 0
+	q.WithLock(func(q *Queue) {
+		q.SetReclaimFunc(func(elem interface{}) { reclaimed++ })
+	})
+	for i := 0; i < 3; i++ {
+		q.Add(i)
+	}
+
+	if batch := q.PopBatch(2); len(batch) != 2 {
+		t.Fatal("popped batch", batch)
+	}
+	if reclaimed != 0 {
+		t.Error("popping a batch reclaimed", reclaimed, "elements")
+	}
+	q.Remove()
+	if reclaimed != 1 {
+		t.Error("removing reclaimed", reclaimed, "elements")
+	}
+}
+
+func TestQueueTeeTo(t *testing.T) {
+	q, audit := New(), New()
+
+	q.Add(0)
+	q.TeeTo(audit)
+	for i := 1; i < 100; i++ {
+		q.Add(i)
+		q.Remove()
+	}
+	q.PrependSlice([]interface{}{100, 101})
+	q.StopTee()
+	q.Add(102)
+
+	if audit.Length() != 101 {
+		t.Fatal("audit queue has length", audit.Length())
+	}
+	for i := 0; i < 101; i++ {
+		if e, _ := audit.Get(i); e.(int) != i+1 {
+			t.Errorf("index %d doesn't contain %d", i, i+1)
+		}
+	}
+}
+
+func TestQueueDrainWhere(t *testing.T) {
+	q := New()
+
+	for i := 0; i < minQueueLen; i++ {
+		q.Add(i)
+	}
+	for i := 0; i < 5; i++ {
+		q.Remove()
+		q.Add(minQueueLen + i)
+	}
+
+	drained := q.DrainWhere(func(elem interface{}) bool { return elem.(int)%3 == 0 })
+	for i, e := range drained {
+		if e.(int) != 6+i*3 {
+			t.Errorf("drained index %d contains %v", i, e)
+		}
+	}
+	if len(drained)+q.Length() != minQueueLen {
+		t.Error("drained", len(drained), "and kept", q.Length())
+	}
+	for i := 0; i < q.Length(); i++ {
+		e, _ := q.Get(i)
+		if e.(int)%3 == 0 || (i > 0 && e.(int) <= q.buf[(q.head+i-1)%len(q.buf)].(int)) {
+			t.Errorf("kept index %d contains %v", i, e)
+		}
+	}
+	if err := q.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	if drained := q.DrainWhere(func(interface{}) bool { return true }); q.Length() != 0 || len(drained) != 11 {
+		t.Error("draining everything left", q.Length())
+	}
+}
+
+func TestQueueRemoveAll(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i % 4)
+	}
+
+	eq := func(a, b interface{}) bool { return a == b }
+	if n := q.RemoveAll(1, eq); n != 25 || q.Length() != 75 {
+		t.Error("removed", n, "leaving", q.Length())
+	}
+	if n := q.RemoveAllComparable(3); n != 25 || q.Length() != 50 {
+		t.Error("removed", n, "leaving", q.Length())
+	}
+	if n := q.RemoveAllComparable("missing"); n != 0 {
+		t.Error("removed", n, "missing elements")
+	}
+
+	for i := 0; i < q.Length(); i++ {
+		if e, _ := q.Get(i); e.(int) != (i%2)*2 {
+			t.Errorf("index %d contains %v", i, e)
+		}
+	}
+	if err := q.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueueShrink(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+	}
+	capacity := len(q.buf)
+
+	q.Shrink()
+	if len(q.buf) != capacity {
+		t.Error("shrinking a mostly full queue changed capacity to", len(q.buf))
+	}
+
+	q.DrainWhere(func(elem interface{}) bool { return elem.(int) >= 400 })
+	if len(q.buf) != capacity {
+		t.Error("draining to 40% full shrank capacity to", len(q.buf))
+	}
+
+	q.Shrink()
+	if len(q.buf) != 800 {
+		t.Error("shrunk queue of 400 elements has capacity", len(q.buf))
+	}
+	for i := 0; i < 400; i++ {
+		if e, _ := q.Get(i); e.(int) != i {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+	}
+
+	q.DrainWhere(func(elem interface{}) bool { return elem.(int) >= 10 })
+	if len(q.buf) != 20 {
+		t.Error("draining to 10 elements left capacity", len(q.buf))
+	}
+	if err := q.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueueRemoveReleasesReference(t *testing.T) {
+	q := New()
+
+	collected := make(chan struct{})
+	elem := &struct{ payload [64]byte }{}
+	runtime.SetFinalizer(elem, func(interface{}) { close(collected) })
+
+	q.Add(elem)
+	q.Add(nil)
+	elem = nil
+	q.Remove()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case <-collected:
+			if q.Length() != 1 {
+				t.Error("queue has length", q.Length())
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("removed element was never collected")
+		}
+	}
+}
+
+func TestQueueEnqueueDequeue(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 100; i++ {
+		q.Enqueue(i)
+	}
+	for i := 0; i < 100; i++ {
+		e, err := q.Dequeue()
+		if err != nil || e.(int) != i {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+	}
+	if q.Length() != 0 {
+		t.Error("dequeued queue has length", q.Length())
+	}
+}
+
+func TestQueueNilElements(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 100; i++ {
+		q.Add(nil)
+		q.Add(i)
+	}
+	if q.Length() != 200 {
+		t.Fatal("queue of nils has length", q.Length())
+	}
+	if err := q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 200; i += 2 {
+		if e, err := q.Get(i); err != nil || e != nil {
+			t.Errorf("index %d contains %v, %v", i, e, err)
+		}
+	}
+	if e, ok := q.Cursor().Next(); !ok || e != nil {
+		t.Error("cursor over nil element returned", e, ok)
+	}
+
+	for i := 0; i < 100; i++ {
+		if e, err := q.Pop(); err != nil || e != nil {
+			t.Errorf("pop %d had value %v, %v", i*2, e, err)
+		}
+		if e, err := q.Pop(); err != nil || e.(int) != i {
+			t.Errorf("pop %d had value %v, %v", i*2+1, e, err)
+		}
+	}
+
+	q.Add(nil)
+	if _, err := q.Peek(); err != nil || q.IsEmpty() {
+		t.Error("queue holding nil should not be empty")
+	}
+}
+
 func TestQueueGetOutOfRangeErrors(t *testing.T) {
 	q := New()
 
@@ -111,11 +999,24 @@ func TestQueuePeekOutOfRangeErrors(t *testing.T) {
 	q.Add(1)
 	q.Remove()
 
-	if _, err := q.Peek(); err == nil {
+	if _, err := q.Peek(); err != ErrEmptyQueue {
 		t.Error("should error when peeking emptied queue")
 	}
 }
 
+func TestQueuePeekOK(t *testing.T) {
+	q := New()
+
+	if _, ok := q.PeekOK(); ok {
+		t.Error("peeking empty queue should not be ok")
+	}
+
+	q.Add(1)
+	if e, ok := q.PeekOK(); !ok || e.(int) != 1 {
+		t.Error("peek had value", e)
+	}
+}
+
 func TestQueueRemoveOutOfRangeErrors(t *testing.T) {
 	q := New()
 
@@ -131,6 +1032,35 @@ func TestQueueRemoveOutOfRangeErrors(t *testing.T) {
 	}
 }
 
+func TestQueueValidate(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+		if err := q.Validate(); err != nil {
+			t.Fatal("adding:", err)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		q.Remove()
+		if err := q.Validate(); err != nil {
+			t.Fatal("removing:", err)
+		}
+	}
+
+	q.Add(1)
+	q.tail = q.head
+	if q.Validate() == nil {
+		t.Error("should error when tail doesn't match count")
+	}
+
+	q = New()
+	q.buf[3] = 1
+	if q.Validate() == nil {
+		t.Error("should error when dead slot is not nil")
+	}
+}
+
 // General warning: Go's benchmark utility (go test -bench .) increases the number of
 // iterations until the benchmarks take a reasonable amount of time to run; memory usage
 // is *NOT* considered. On my machine, these benchmarks hit around ~1GB before they've had
@@ -158,6 +1088,22 @@ func BenchmarkQueueGet(b *testing.B) {
 	}
 }
 
+func BenchmarkQueueEmptyPoll(b *testing.B) {
+	q := New()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q.Peek()
+	}
+}
+
+func BenchmarkQueueEmptyPollOK(b *testing.B) {
+	q := New()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q.PeekOK()
+	}
+}
+
 func BenchmarkQueueTickTock(b *testing.B) {
 	q := New()
 	for i := 0; i < b.N; i++ {
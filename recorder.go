@@ -0,0 +1,34 @@
+package queue
+
+// An Operation is a mutating call made on a queue constructed WithRecorder.
+// Name is the method called and Args holds its arguments as they were passed;
+// function arguments are not recorded.
+type Operation struct {
+	Name string
+	Args []interface{}
+}
+
+// OperationLog returns the most recent operations made on a queue
+// constructed WithRecorder, oldest first, or nil for any other queue.
+func (q *Queue) OperationLog() []Operation {
+	if !q.recording() {
+		return nil
+	}
+
+	recorder := q.hooks.recorder
+	log := make([]Operation, recorder.count)
+	for i := range log {
+		log[i] = recorder.buf[(recorder.head+i)%len(recorder.buf)].(Operation)
+	}
+	return log
+}
+
+// reports whether the queue logs its operations, which mutating methods check
+// before calling record so as not to build its arguments otherwise
+func (q *Queue) recording() bool {
+	return q.hooks != nil && q.hooks.recorder != nil
+}
+
+func (q *Queue) record(name string, args ...interface{}) {
+	q.hooks.recorder.Slide(Operation{Name: name, Args: args})
+}
@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	q := NewWithOptions(WithRecorder(5))
+
+	if len(q.OperationLog()) != 0 {
+		t.Error("new queue has operations logged")
+	}
+
+	q.Add(1)
+	q.Add(2)
+	q.Pop()
+	q.PrependSlice([]interface{}{3, 4})
+	q.Remove()
+	q.PopRandom(rand.New(rand.NewSource(1)))
+	q.Remove()
+	q.Remove() // fails on the empty queue, so is not logged
+
+	log := q.OperationLog()
+	want := []string{"Pop", "PrependSlice", "Remove", "PopRandom", "Remove"}
+	if len(log) != len(want) {
+		t.Fatal("logged", len(log), "operations")
+	}
+	for i, op := range log {
+		if op.Name != want[i] {
+			t.Errorf("operation %d was %s, expected %s", i, op.Name, want[i])
+		}
+	}
+	if len(log[1].Args) != 1 || len(log[1].Args[0].([]interface{})) != 2 {
+		t.Error("PrependSlice was logged with", log[1].Args)
+	}
+
+	if New().OperationLog() != nil {
+		t.Error("queue without recorder has a log")
+	}
+}
+
+func TestRecorderNested(t *testing.T) {
+	q := NewWithOptions(WithRecorder(10))
+	q.window = 1
+
+	q.Slide(1)
+	q.Slide(2)
+
+	log := q.OperationLog()
+	if len(log) != 2 || log[0].Name != "Slide" || log[1].Args[0] != 2 {
+		t.Error("sliding logged", log)
+	}
+}
+
+func TestRecorderSize(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("should panic with recorder size", n)
+				}
+			}()
+			WithRecorder(n)
+		}()
+	}
+}
@@ -0,0 +1,104 @@
+package queue
+
+// RollingStats maintains the count, sum, average, minimum and maximum of
+// the most recent values added to it, over a fixed-size sliding window. Add
+// is amortized O(1) and every statistic is read in O(1): the minimum and
+// maximum are tracked with monotonic queues rather than by rescanning the
+// window. The sum is kept as a running total, so it accumulates floating
+// point rounding error over very long runs.
+type RollingStats struct {
+	window   *Queue
+	min, max *Queue
+	sum      float64
+	seq      int
+}
+
+// an entry in a monotonic queue: a value and its position in the stream
+type rollingEntry struct {
+	val float64
+	seq int
+}
+
+// NewRollingStats constructs and returns a new RollingStats over the last
+// size values. It panics if size is less than 1.
+func NewRollingStats(size int) *RollingStats {
+	if size < 1 {
+		panic("queue: NewRollingStats() called with size less than 1")
+	}
+	return &RollingStats{
+		window: NewWindow(size),
+		min:    New(),
+		max:    New(),
+	}
+}
+
+// Add adds a value to the window, evicting the oldest value if the window
+// is full.
+func (r *RollingStats) Add(val float64) {
+	if evicted, ok := r.window.Slide(val); ok {
+		r.sum -= evicted.(float64)
+		evictedSeq := r.seq - r.window.window
+		for _, q := range []*Queue{r.min, r.max} {
+			if q.count > 0 && q.buf[q.head].(rollingEntry).seq == evictedSeq {
+				q.remove()
+			}
+		}
+	}
+	r.sum += val
+
+	// each monotonic queue only keeps values that could still become the
+	// minimum or maximum: those not beaten by a newer value
+	for r.min.count > 0 && lastEntry(r.min).val >= val {
+		r.min.removeTail()
+	}
+	for r.max.count > 0 && lastEntry(r.max).val <= val {
+		r.max.removeTail()
+	}
+	entry := rollingEntry{val, r.seq}
+	r.min.Add(entry)
+	r.max.Add(entry)
+	r.seq++
+}
+
+// Count returns the number of values currently in the window.
+func (r *RollingStats) Count() int {
+	return r.window.Length()
+}
+
+// Sum returns the sum of the values in the window.
+func (r *RollingStats) Sum() float64 {
+	return r.sum
+}
+
+// Average returns the mean of the values in the window. This call returns
+// ErrEmptyQueue if no values have been added.
+func (r *RollingStats) Average() (float64, error) {
+	if r.window.count == 0 {
+		return 0, ErrEmptyQueue
+	}
+	return r.sum / float64(r.window.count), nil
+}
+
+// Min returns the smallest value in the window. This call returns
+// ErrEmptyQueue if no values have been added.
+func (r *RollingStats) Min() (float64, error) {
+	return r.front(r.min)
+}
+
+// Max returns the largest value in the window. This call returns
+// ErrEmptyQueue if no values have been added.
+func (r *RollingStats) Max() (float64, error) {
+	return r.front(r.max)
+}
+
+func (r *RollingStats) front(q *Queue) (float64, error) {
+	if q.count == 0 {
+		return 0, ErrEmptyQueue
+	}
+	return q.buf[q.head].(rollingEntry).val, nil
+}
+
+// returns the newest entry of a non-empty monotonic queue
+func lastEntry(q *Queue) rollingEntry {
+	return q.buf[(q.tail-1+len(q.buf))%len(q.buf)].(rollingEntry)
+}
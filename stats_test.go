@@ -0,0 +1,61 @@
+package queue
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRollingStats(t *testing.T) {
+	const size = 20
+	r := NewRollingStats(size)
+	rng := rand.New(rand.NewSource(1))
+
+	if _, err := r.Min(); err != ErrEmptyQueue {
+		t.Error("should error when taking min of no values")
+	}
+	if _, err := r.Average(); err != ErrEmptyQueue {
+		t.Error("should error when averaging no values")
+	}
+
+	var values []float64
+	for i := 0; i < 1000; i++ {
+		// a small range makes repeated extremes, and so ties, likely
+		val := float64(rng.Intn(30))
+		r.Add(val)
+		values = append(values, val)
+		if len(values) > size {
+			values = values[1:]
+		}
+
+		min, max, sum := values[0], values[0], 0.0
+		for _, v := range values {
+			if v < min {
+				min = v
+			}
+			if v > max {
+				max = v
+			}
+			sum += v
+		}
+
+		gotMin, _ := r.Min()
+		gotMax, _ := r.Max()
+		gotAvg, _ := r.Average()
+		if gotMin != min || gotMax != max || r.Sum() != sum || r.Count() != len(values) {
+			t.Fatalf("step %d: min %v max %v sum %v count %d, expected %v %v %v %d",
+				i, gotMin, gotMax, r.Sum(), r.Count(), min, max, sum, len(values))
+		}
+		if gotAvg != sum/float64(len(values)) {
+			t.Fatalf("step %d: average %v", i, gotAvg)
+		}
+	}
+}
+
+func TestRollingStatsSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("should panic with size 0")
+		}
+	}()
+	NewRollingStats(0)
+}
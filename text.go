@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DumpText writes the text of each element to w in order, separated by sep.
+// Elements implementing encoding.TextMarshaler are written with MarshalText,
+// anything else with fmt.Sprint. No escaping is done, so an element whose
+// text contains sep is rejected with an error before anything is written.
+func (q *Queue) DumpText(w io.Writer, sep string) error {
+	if sep == "" {
+		return errors.New("queue: DumpText() called with empty separator")
+	}
+
+	var buf bytes.Buffer
+	for i := 0; i < q.count; i++ {
+		text, err := elemText(q.buf[(q.head+i)%len(q.buf)])
+		if err != nil {
+			return err
+		}
+		if strings.Contains(text, sep) {
+			return fmt.Errorf("queue: element %d contains separator %q", i, sep)
+		}
+
+		if i > 0 {
+			buf.WriteString(sep)
+		}
+		buf.WriteString(text)
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// LoadText reads text written by DumpText from r and returns a new queue
+// holding each sep-separated field as a string. Empty input produces an empty
+// queue, so a dumped queue holding a single empty string does not round-trip.
+func LoadText(r io.Reader, sep string) (*Queue, error) {
+	if sep == "" {
+		return nil, errors.New("queue: LoadText() called with empty separator")
+	}
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+
+	q := New()
+	if buf.Len() == 0 {
+		return q, nil
+	}
+	for _, field := range strings.Split(buf.String(), sep) {
+		q.Add(field)
+	}
+	return q, nil
+}
+
+func elemText(elem interface{}) (string, error) {
+	if m, ok := elem.(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	return fmt.Sprint(elem), nil
+}
@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestTextRoundTrip(t *testing.T) {
+	q := New()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	var buf bytes.Buffer
+	if err := q.DumpText(&buf, "\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadText(&buf, "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Length() != 100 {
+		t.Fatal("loaded queue has length", loaded.Length())
+	}
+	for i := 0; i < 100; i++ {
+		if e, _ := loaded.Get(i); e.(string) != strconv.Itoa(i) {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+	}
+}
+
+func TestTextMarshaler(t *testing.T) {
+	q := New()
+	q.Add(net.IPv4(10, 0, 0, 1))
+	q.Add("host")
+
+	var buf bytes.Buffer
+	if err := q.DumpText(&buf, ","); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "10.0.0.1,host" {
+		t.Error("dumped text was", buf.String())
+	}
+}
+
+func TestTextSeparatorErrors(t *testing.T) {
+	q := New()
+	q.Add("a,b")
+
+	var buf bytes.Buffer
+	if q.DumpText(&buf, ",") == nil {
+		t.Error("should error when element contains separator")
+	}
+	if buf.Len() != 0 {
+		t.Error("failed dump wrote", buf.String())
+	}
+	if q.DumpText(&buf, "") == nil {
+		t.Error("should error on empty separator")
+	}
+	if _, err := LoadText(&buf, ""); err == nil {
+		t.Error("should error on empty separator")
+	}
+}
+
+func TestTextLoadEmpty(t *testing.T) {
+	q, err := LoadText(&bytes.Buffer{}, ",")
+	if err != nil || q.Length() != 0 {
+		t.Error("loading empty input should give empty queue")
+	}
+}
@@ -11,6 +11,8 @@ type ThreadSafeQueue struct {
 	lock *sync.Mutex
 }
 
+var _ Interface = (*ThreadSafeQueue)(nil)
+
 // Creates and returns a new thread safe queue.
 func NewThreadSafe() *ThreadSafeQueue {
 	return &ThreadSafeQueue{
@@ -35,6 +37,11 @@ func (t *ThreadSafeQueue) Add(elem interface{}) {
 	t.q.Add(elem)
 }
 
+// Enqueue is an alias for Add.
+func (t *ThreadSafeQueue) Enqueue(elem interface{}) {
+	t.Add(elem)
+}
+
 // Peek returns the element at the head of the queue. This call errors
 // if the queue is empty.
 func (t *ThreadSafeQueue) Peek() (interface{}, error) {
@@ -61,6 +68,40 @@ func (t *ThreadSafeQueue) Pop() (interface{}, error) {
 	return t.q.Pop()
 }
 
+// Dequeue is an alias for Pop.
+func (t *ThreadSafeQueue) Dequeue() (interface{}, error) {
+	return t.Pop()
+}
+
+// PopAll removes every element from the queue in order, calling fn on each,
+// until the queue is empty. The lock is held for the whole drain, so other
+// goroutines can't add elements partway through, and fn must not call back
+// into the ThreadSafeQueue or it will deadlock.
+func (t *ThreadSafeQueue) PopAll(fn func(elem interface{})) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.q.PopAll(fn)
+}
+
+// PopWithLen removes and returns the first item from the queue, along with
+// the number of elements remaining, under a single lock.
+func (t *ThreadSafeQueue) PopWithLen() (interface{}, int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.q.PopWithLen()
+}
+
+// ReplaceAll replaces the contents of the queue with elems, in order, under
+// a single lock, so other goroutines see either the old or new contents.
+func (t *ThreadSafeQueue) ReplaceAll(elems []interface{}) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.q.ReplaceAll(elems)
+}
+
 // Remove removes the element from the front of the queue. If you actually
 // want the element, call Peek first. This call errors if the queue is empty.
 func (t *ThreadSafeQueue) Remove() error {
@@ -69,3 +110,71 @@ func (t *ThreadSafeQueue) Remove() error {
 
 	return t.q.Remove()
 }
+
+// PeekBatch returns up to max elements from the head of the queue, in
+// order, without removing them. It returns nil if the queue is empty.
+func (t *ThreadSafeQueue) PeekBatch(max int) []interface{} {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.peekBatch(max)
+}
+
+// PopBatch removes and returns up to max elements from the head of the
+// queue, in order. The whole batch is taken under a single lock, so no other
+// goroutine can remove elements in between. It returns nil if the queue is
+// empty.
+func (t *ThreadSafeQueue) PopBatch(max int) []interface{} {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	batch := t.peekBatch(max)
+	if len(batch) == 0 {
+		return batch
+	}
+	if t.q.recording() {
+		t.q.record("PopBatch", len(batch))
+	}
+
+	// the batch is handed back, so is taken off without being reclaimed
+	for i := 0; i < len(batch); i++ {
+		t.q.removeHead()
+	}
+	t.q.maybeShrink()
+	return batch
+}
+
+// Snapshot returns a new slice holding the elements of the queue, in order,
+// as of a single point in time. The copy is made while holding the lock, so
+// snapshotting a very large queue briefly blocks other goroutines.
+func (t *ThreadSafeQueue) Snapshot() []interface{} {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.q.ToSlice()
+}
+
+// WithLock calls fn with the underlying queue while holding the lock,
+// allowing callers to compose an arbitrary sequence of operations atomically.
+// The *Queue passed to fn must not be retained or used after fn returns, and
+// fn must not call back into the ThreadSafeQueue or it will deadlock.
+func (t *ThreadSafeQueue) WithLock(fn func(*Queue)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	fn(t.q)
+}
+
+// copies up to max head elements into a new slice; the lock must be held
+func (t *ThreadSafeQueue) peekBatch(max int) []interface{} {
+	if max > t.q.count {
+		max = t.q.count
+	}
+	if max <= 0 {
+		return nil
+	}
+
+	batch := make([]interface{}, max)
+	t.q.copyInto(batch)
+	return batch
+}
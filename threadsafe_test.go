@@ -83,6 +83,92 @@ func TestTsQueuePops(t *testing.T) {
 	}
 }
 
+func TestTsQueuePopAll(t *testing.T) {
+	q := NewThreadSafe()
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+	}
+
+	popped := 0
+	q.PopAll(func(elem interface{}) {
+		if elem.(int) != popped {
+			t.Errorf("pop %d had value %v", popped, elem)
+		}
+		popped++
+	})
+
+	if popped != 1000 || q.Length() != 0 {
+		t.Error("popped", popped, "elements, leaving", q.Length())
+	}
+}
+
+func TestTsQueuePopWithLen(t *testing.T) {
+	q := NewThreadSafe()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	for i := 0; i < 100; i++ {
+		e, remaining, err := q.PopWithLen()
+		if err != nil || e.(int) != i {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+		if remaining != 100-i-1 {
+			t.Error("popping: queue with", 100-i-1, "elements reported", remaining)
+		}
+	}
+
+	if _, remaining, err := q.PopWithLen(); err == nil || remaining != 0 {
+		t.Error("should error when popping empty queue")
+	}
+}
+
+func TestTsQueueReplaceAll(t *testing.T) {
+	q := NewThreadSafe()
+
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+		q.Remove()
+		q.Add(i)
+	}
+
+	for _, n := range []int{5, 0, 100} {
+		elems := make([]interface{}, n)
+		for i := range elems {
+			elems[i] = i + n
+		}
+
+		q.ReplaceAll(elems)
+		if q.Length() != n {
+			t.Fatal("replaced queue has length", q.Length(), "expected", n)
+		}
+		for i := 0; i < n; i++ {
+			if e, _ := q.Get(i); e.(int) != i+n {
+				t.Errorf("index %d doesn't contain %d", i, i+n)
+			}
+		}
+	}
+}
+
+func TestTsQueueEnqueueDequeue(t *testing.T) {
+	q := NewThreadSafe()
+
+	for i := 0; i < 100; i++ {
+		q.Enqueue(i)
+	}
+	for i := 0; i < 100; i++ {
+		e, err := q.Dequeue()
+		if err != nil || e.(int) != i {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+	}
+	if q.Length() != 0 {
+		t.Error("dequeued queue has length", q.Length())
+	}
+}
+
 func TestTsQueueGetOutOfRangeErrors(t *testing.T) {
 	q := NewThreadSafe()
 
@@ -131,6 +217,73 @@ func TestTsQueueRemoveOutOfRangeErrors(t *testing.T) {
 	}
 }
 
+func TestTsQueueBatches(t *testing.T) {
+	q := NewThreadSafe()
+
+	if q.PeekBatch(5) != nil || q.PopBatch(5) != nil {
+		t.Error("batch of empty queue should be nil")
+	}
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+	}
+
+	peeked := q.PeekBatch(10)
+	if len(peeked) != 10 || q.Length() != 1000 {
+		t.Error("peek batch had length", len(peeked), "and queue length", q.Length())
+	}
+
+	for i := 0; i < 1000; i += 100 {
+		batch := q.PopBatch(100)
+		if len(batch) != 100 {
+			t.Fatal("pop batch had length", len(batch))
+		}
+		for j, e := range batch {
+			if e.(int) != i+j {
+				t.Errorf("batch index %d doesn't contain %d", j, i+j)
+			}
+		}
+	}
+
+	q.Add(1)
+	if batch := q.PopBatch(10); len(batch) != 1 || q.Length() != 0 {
+		t.Error("pop batch larger than queue had length", len(batch))
+	}
+}
+
+func TestTsQueueSnapshot(t *testing.T) {
+	q := NewThreadSafe()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	snapshot := q.Snapshot()
+	q.Add(100)
+
+	if len(snapshot) != 100 {
+		t.Fatal("snapshot has length", len(snapshot))
+	}
+	for i, e := range snapshot {
+		if e.(int) != i {
+			t.Errorf("index %d doesn't contain %d", i, i)
+		}
+	}
+}
+
+func TestTsQueueWithLock(t *testing.T) {
+	q := NewThreadSafe()
+
+	q.WithLock(func(inner *Queue) {
+		inner.Add(1)
+		inner.Add(2)
+	})
+
+	if q.Length() != 2 {
+		t.Error("queue modified under lock has length", q.Length())
+	}
+}
+
 // General warning: Go's benchmark utility (go test -bench .) increases the number of
 // iterations until the benchmarks take a reasonable amount of time to run; memory usage
 // is *NOT* considered. On my machine, these benchmarks hit around ~1GB before they've had
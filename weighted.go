@@ -0,0 +1,68 @@
+package queue
+
+// A Band is one priority class of a WeightedQueue: a queue of elements and
+// the number of them to pop in each scheduling round.
+type Band struct {
+	Queue  *Queue
+	Weight int
+}
+
+// A WeightedQueue drains several queues in weighted round-robin order. Each
+// round pops up to Weight elements from each band in turn, so bands with
+// weights 3 and 1 give the first three pops for every one from the second.
+// Empty bands are skipped, handing their turn to the next band.
+type WeightedQueue struct {
+	bands       []Band
+	cur, served int
+}
+
+// NewWeighted constructs and returns a new WeightedQueue over bands, in the
+// order given. It panics if any weight is less than 1.
+func NewWeighted(bands ...Band) *WeightedQueue {
+	for _, b := range bands {
+		if b.Weight < 1 {
+			panic("queue: NewWeighted() called with band weight less than 1")
+		}
+	}
+	return &WeightedQueue{bands: bands}
+}
+
+// Length returns the number of elements currently stored across all bands.
+func (w *WeightedQueue) Length() int {
+	n := 0
+	for _, b := range w.bands {
+		n += b.Queue.Length()
+	}
+	return n
+}
+
+// Add puts an element on the end of the given band's queue. If the band is
+// invalid, the call returns ErrOutOfRange.
+func (w *WeightedQueue) Add(elem interface{}, band int) error {
+	if band < 0 || band >= len(w.bands) {
+		return ErrOutOfRange
+	}
+	w.bands[band].Queue.Add(elem)
+	return nil
+}
+
+// Pop removes and returns the next element according to the weighted
+// schedule. This call returns ErrEmptyQueue if every band is empty.
+func (w *WeightedQueue) Pop() (interface{}, error) {
+	if len(w.bands) == 0 {
+		return nil, ErrEmptyQueue
+	}
+
+	// one extra step lets the current band be revisited with a fresh turn
+	for i := 0; i <= len(w.bands); i++ {
+		b := w.bands[w.cur]
+		if w.served < b.Weight && b.Queue.Length() > 0 {
+			w.served++
+			return b.Queue.Pop()
+		}
+
+		w.cur = (w.cur + 1) % len(w.bands)
+		w.served = 0
+	}
+	return nil, ErrEmptyQueue
+}
@@ -0,0 +1,59 @@
+package queue
+
+import "testing"
+
+func TestWeightedQueueSchedule(t *testing.T) {
+	q := NewWeighted(Band{New(), 3}, Band{New(), 1})
+
+	for i := 0; i < 6; i++ {
+		q.Add("high", 0)
+	}
+	for i := 0; i < 4; i++ {
+		q.Add("low", 1)
+	}
+	if q.Add("none", 2) != ErrOutOfRange {
+		t.Error("should error when adding to missing band")
+	}
+
+	want := []string{"high", "high", "high", "low", "high", "high", "high", "low", "low", "low"}
+	for i, w := range want {
+		e, err := q.Pop()
+		if err != nil || e.(string) != w {
+			t.Errorf("pop %d had value %v, expected %s", i, e, w)
+		}
+	}
+
+	if _, err := q.Pop(); err != ErrEmptyQueue || q.Length() != 0 {
+		t.Error("should error when popping empty queue")
+	}
+	if _, err := NewWeighted().Pop(); err != ErrEmptyQueue {
+		t.Error("should error when popping queue without bands")
+	}
+}
+
+func TestWeightedQueueSkipsEmpty(t *testing.T) {
+	q := NewWeighted(Band{New(), 2}, Band{New(), 2}, Band{New(), 2})
+
+	q.Add(1, 2)
+	if e, _ := q.Pop(); e != 1 {
+		t.Error("pop skipping empty bands had value", e)
+	}
+
+	q.Add(2, 0)
+	q.Add(3, 1)
+	if q.Length() != 2 {
+		t.Error("queue has length", q.Length())
+	}
+	if e, _ := q.Pop(); e != 2 {
+		t.Error("pop after wrapping had value", e)
+	}
+}
+
+func TestWeightedQueueInvalidWeight(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("should panic with zero weight")
+		}
+	}()
+	NewWeighted(Band{New(), 0})
+}